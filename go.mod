@@ -3,7 +3,6 @@ module github.com/dredge-dev/dredge
 go 1.15
 
 require (
-	github.com/creack/pty v1.1.18 // indirect
 	github.com/fatih/color v1.14.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/manifoldco/promptui v0.9.0
@@ -16,7 +16,7 @@ type Field struct {
 
 type Command struct {
 	Name       string
-	Inputs     []string
+	Inputs     []InputRequest
 	OutputType string
 }
 
@@ -41,6 +41,8 @@ type UserInteractionCallbacks interface {
 type ExecutionCallbacks interface {
 	ExecuteResourceCommand(resource string, command string) (*CommandOutput, error)
 	SetEnv(name string, value interface{}) error
+	GetEnv(name string) (interface{}, bool)
+	Scope(vars map[string]interface{}) Callbacks
 	Template(input string) (string, error)
 }
 
@@ -84,10 +86,15 @@ type InputType int
 const (
 	Text InputType = iota
 	Select
+	Bool
+	Number
+	MultiSelect
+	Password
+	File
 )
 
 func (i InputType) String() string {
-	return [...]string{"Text", "Select"}[i]
+	return [...]string{"Text", "Select", "Bool", "Number", "MultiSelect", "Password", "File"}[i]
 }
 
 type InputRequest struct {
@@ -96,4 +103,12 @@ type InputRequest struct {
 	Type         InputType
 	Values       []string
 	DefaultValue string
+	Min          *int
+	Max          *int
+	Required     bool
+	Pattern      string
+	MinLength    int
+	MaxLength    int
+	// ValidationMessage replaces the error returned by ValidateValue
+	ValidationMessage string
 }
@@ -1,6 +1,15 @@
 package api
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
 
 func (r *ResourceDefinition) GetCommand(name string) (*Command, error) {
 	for _, c := range r.Commands {
@@ -10,3 +19,154 @@ func (r *ResourceDefinition) GetCommand(name string) (*Command, error) {
 	}
 	return nil, fmt.Errorf("could not find %s command for %s resource", name, r.Name)
 }
+
+func (c *Command) GetInput(name string) (*InputRequest, bool) {
+	for _, i := range c.Inputs {
+		if i.Name == name {
+			return &i, true
+		}
+	}
+	return nil, false
+}
+
+func NewResourceDefinition(rd config.ResourceDefinition) ResourceDefinition {
+	result := ResourceDefinition{
+		Name: rd.Name,
+	}
+	for _, f := range rd.Fields {
+		result.Fields = append(result.Fields, Field{
+			Name:        f.Name,
+			Description: f.Description,
+			Type:        f.GetType(),
+		})
+	}
+	for _, c := range rd.Commands {
+		var inputs []InputRequest
+		for _, i := range c.Inputs {
+			inputs = append(inputs, NewInputRequest(i))
+		}
+		result.Commands = append(result.Commands, Command{
+			Name:       c.Name,
+			Inputs:     inputs,
+			OutputType: c.OutputType,
+		})
+	}
+	return result
+}
+
+func NewInputRequest(input config.Input) InputRequest {
+	return InputRequest{
+		Name:         input.Name,
+		Description:  input.Description,
+		Type:         toInputType(input.Type),
+		Values:       input.Values,
+		DefaultValue: input.DefaultValue,
+		Min:          input.Min,
+		Max:          input.Max,
+		Required:     input.Required,
+		Pattern:      input.Pattern,
+		MinLength:    input.MinLength,
+		MaxLength:    input.MaxLength,
+
+		ValidationMessage: input.ValidationMessage,
+	}
+}
+
+func toInputType(t string) InputType {
+	switch t {
+	case config.INPUT_SELECT:
+		return Select
+	case config.INPUT_BOOL:
+		return Bool
+	case config.INPUT_NUMBER:
+		return Number
+	case config.INPUT_MULTISELECT:
+		return MultiSelect
+	case config.INPUT_PASSWORD:
+		return Password
+	case config.INPUT_FILE:
+		return File
+	}
+	return Text
+}
+
+// ValidateValue checks if value is a valid value for the input type and
+// matches the validation rules of the input.
+func (r InputRequest) ValidateValue(value string) error {
+	err := r.validateValue(value)
+	if err != nil && r.ValidationMessage != "" {
+		return errors.New(r.ValidationMessage)
+	}
+	return err
+}
+
+func (r InputRequest) validateValue(value string) error {
+	if value == "" {
+		if r.Required {
+			return fmt.Errorf("input %s is required", r.Name)
+		}
+		if r.Type == Text || r.Type == Password || r.Type == MultiSelect {
+			return nil
+		}
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for input %s: %v", r.Name, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("invalid value (%s) for input %s (should match %s)", value, r.Name, r.Pattern)
+		}
+	}
+	if len(value) < r.MinLength {
+		return fmt.Errorf("invalid value (%s) for input %s (minimum length is %d)", value, r.Name, r.MinLength)
+	}
+	if r.MaxLength > 0 && len(value) > r.MaxLength {
+		return fmt.Errorf("invalid value (%s) for input %s (maximum length is %d)", value, r.Name, r.MaxLength)
+	}
+	switch r.Type {
+	case Select:
+		if !r.hasValue(value) {
+			return fmt.Errorf("invalid value (%s) for input %s (valid options are: %s)", value, r.Name, strings.Join(r.Values, ", "))
+		}
+	case MultiSelect:
+		for _, v := range config.SplitList(value) {
+			if !r.hasValue(v) {
+				return fmt.Errorf("invalid value (%s) for input %s (valid options are: %s)", v, r.Name, strings.Join(r.Values, ", "))
+			}
+		}
+	case Bool:
+		if !config.IsTrue(value) && !config.IsFalse(value) {
+			return fmt.Errorf("invalid value (%s) for input %s (expected yes or no)", value, r.Name)
+		}
+	case Number:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value (%s) for input %s (expected a number)", value, r.Name)
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Errorf("invalid value (%s) for input %s (minimum is %d)", value, r.Name, *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Errorf("invalid value (%s) for input %s (maximum is %d)", value, r.Name, *r.Max)
+		}
+	case File:
+		info, err := os.Stat(value)
+		if err != nil {
+			return fmt.Errorf("invalid value (%s) for input %s (file does not exist)", value, r.Name)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("invalid value (%s) for input %s (expected a file, found a directory)", value, r.Name)
+		}
+	}
+	return nil
+}
+
+func (r InputRequest) hasValue(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
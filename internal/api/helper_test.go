@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateValue(t *testing.T) {
+	min := 1
+	max := 3
+	tests := map[string]struct {
+		input  config.Input
+		value  string
+		errMsg string
+	}{
+		"text": {
+			input: config.Input{Name: "title"},
+			value: "anything",
+		},
+		"select": {
+			input: config.Input{Name: "env", Type: config.INPUT_SELECT, Values: []string{"dev", "prod"}},
+			value: "prod",
+		},
+		"invalid select": {
+			input:  config.Input{Name: "env", Type: config.INPUT_SELECT, Values: []string{"dev", "prod"}},
+			value:  "test",
+			errMsg: "invalid value (test) for input env (valid options are: dev, prod)",
+		},
+		"multiselect": {
+			input: config.Input{Name: "services", Type: config.INPUT_MULTISELECT, Values: []string{"api", "web"}},
+			value: "api, web",
+		},
+		"invalid multiselect": {
+			input:  config.Input{Name: "services", Type: config.INPUT_MULTISELECT, Values: []string{"api", "web"}},
+			value:  "api,db",
+			errMsg: "invalid value (db) for input services (valid options are: api, web)",
+		},
+		"bool": {
+			input: config.Input{Name: "confirm", Type: config.INPUT_BOOL},
+			value: "no",
+		},
+		"invalid bool": {
+			input:  config.Input{Name: "confirm", Type: config.INPUT_BOOL},
+			value:  "maybe",
+			errMsg: "invalid value (maybe) for input confirm (expected yes or no)",
+		},
+		"number": {
+			input: config.Input{Name: "instances", Type: config.INPUT_NUMBER, Min: &min, Max: &max},
+			value: "2",
+		},
+		"number not a number": {
+			input:  config.Input{Name: "instances", Type: config.INPUT_NUMBER},
+			value:  "two",
+			errMsg: "invalid value (two) for input instances (expected a number)",
+		},
+		"number too small": {
+			input:  config.Input{Name: "instances", Type: config.INPUT_NUMBER, Min: &min, Max: &max},
+			value:  "0",
+			errMsg: "invalid value (0) for input instances (minimum is 1)",
+		},
+		"number too large": {
+			input:  config.Input{Name: "instances", Type: config.INPUT_NUMBER, Min: &min, Max: &max},
+			value:  "4",
+			errMsg: "invalid value (4) for input instances (maximum is 3)",
+		},
+		"file": {
+			input: config.Input{Name: "file", Type: config.INPUT_FILE},
+			value: "helper.go",
+		},
+		"missing file": {
+			input:  config.Input{Name: "file", Type: config.INPUT_FILE},
+			value:  "missing.go",
+			errMsg: "invalid value (missing.go) for input file (file does not exist)",
+		},
+		"required": {
+			input:  config.Input{Name: "env", Required: true},
+			value:  "",
+			errMsg: "input env is required",
+		},
+		"empty optional with pattern": {
+			input: config.Input{Name: "version", Pattern: "^v[0-9]+$"},
+			value: "",
+		},
+		"pattern": {
+			input: config.Input{Name: "version", Pattern: "^v[0-9]+$"},
+			value: "v12",
+		},
+		"not matching pattern": {
+			input:  config.Input{Name: "version", Pattern: "^v[0-9]+$"},
+			value:  "12",
+			errMsg: "invalid value (12) for input version (should match ^v[0-9]+$)",
+		},
+		"too short": {
+			input:  config.Input{Name: "name", MinLength: 3},
+			value:  "ab",
+			errMsg: "invalid value (ab) for input name (minimum length is 3)",
+		},
+		"too long": {
+			input:  config.Input{Name: "name", MaxLength: 3},
+			value:  "abcd",
+			errMsg: "invalid value (abcd) for input name (maximum length is 3)",
+		},
+		"validation message": {
+			input:  config.Input{Name: "version", Pattern: "^v[0-9]+$", ValidationMessage: "version should look like v1"},
+			value:  "1",
+			errMsg: "version should look like v1",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		err := NewInputRequest(test.input).ValidateValue(test.value)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
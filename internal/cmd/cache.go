@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/dredge-dev/dredge/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+func createCacheCommand() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of shell steps",
+	}
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clean",
+		Short: "Clear the cache, so all shell steps with sources run again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return workflow.CleanCache()
+		},
+	})
+	return cacheCmd
+}
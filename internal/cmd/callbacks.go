@@ -4,22 +4,26 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/secrets"
 	"github.com/manifoldco/promptui"
 	"github.com/pkg/browser"
 )
 
 type CliCallbacks struct {
-	Reader  io.Reader
-	Writer  io.Writer
-	Verbose *bool
+	Reader         io.Reader
+	Writer         io.Writer
+	Verbose        *bool
+	NonInteractive *bool
 }
 
 func (c CliCallbacks) Log(level api.LogLevel, msg string, args ...interface{}) error {
 	if *c.Verbose || (level != api.Debug && level != api.Trace) {
-		fmt.Fprintf(c.Writer, "[%s] %s %s\n", time.Now().Format(time.RFC3339), level, fmt.Sprintf(msg, args...))
+		fmt.Fprintf(c.Writer, "[%s] %s %s\n", time.Now().Format(time.RFC3339), level, secrets.Mask(fmt.Sprintf(msg, args...)))
 	}
 	return nil
 }
@@ -36,36 +40,108 @@ func (c CliCallbacks) RequestInput(inputRequests []api.InputRequest) (map[string
 	return inputs, nil
 }
 
+func (c CliCallbacks) interactive() bool {
+	return c.NonInteractive == nil || !*c.NonInteractive
+}
+
 func (c CliCallbacks) readInput(ir api.InputRequest) (string, error) {
-	if ir.Type == api.Text {
-		fmt.Printf("%s [%s]: ", ir.Description, ir.Name)
-		scanner := bufio.NewScanner(c.Reader)
-		if scanner.Scan() {
-			value := scanner.Text()
-			return value, nil
+	if !c.interactive() {
+		if ir.DefaultValue != "" {
+			return ir.DefaultValue, nil
 		}
-		if err := scanner.Err(); err != nil {
-			return "", err
+		if !ir.Required && (ir.Type == api.Text || ir.Type == api.MultiSelect) {
+			return "", nil
 		}
-	} else if ir.Type == api.Select {
+		return "", fmt.Errorf("input %s is required but cannot be requested in non-interactive mode", ir.Name)
+	}
+	switch ir.Type {
+	case api.Text, api.Number, api.MultiSelect, api.File:
+		return c.readLine(ir)
+	case api.Select:
 		prompt := promptui.Select{
-			Label: fmt.Sprintf("%s [%s]", ir.Description, ir.Name),
-			Items: ir.Values,
+			Label:     fmt.Sprintf("%s [%s]", ir.Description, ir.Name),
+			Items:     ir.Values,
+			CursorPos: indexOf(ir.Values, ir.DefaultValue),
 		}
 		_, value, err := prompt.Run()
 		if err != nil {
 			return "", err
 		}
 		return value, nil
+	case api.Bool:
+		cursor := 0
+		if config.IsFalse(ir.DefaultValue) {
+			cursor = 1
+		}
+		prompt := promptui.Select{
+			Label:     fmt.Sprintf("%s [%s]", ir.Description, ir.Name),
+			Items:     []string{"yes", "no"},
+			CursorPos: cursor,
+		}
+		_, value, err := prompt.Run()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%t", value == "yes"), nil
+	case api.Password:
+		prompt := promptui.Prompt{
+			Label:    fmt.Sprintf("%s [%s]", ir.Description, ir.Name),
+			Mask:     '*',
+			Validate: ir.ValidateValue,
+		}
+		return prompt.Run()
 	}
 	return "", fmt.Errorf("InputRequest.Type %d not implemented", ir.Type)
 }
 
+// readLine reads a value from the reader, until a valid value is entered.
+// Empty input results in the default value.
+func (c CliCallbacks) readLine(ir api.InputRequest) (string, error) {
+	hint := ""
+	if ir.Type == api.MultiSelect {
+		hint = fmt.Sprintf(" (comma separated: %s)", strings.Join(ir.Values, ", "))
+	}
+	if ir.DefaultValue != "" {
+		hint += fmt.Sprintf(" (%s)", ir.DefaultValue)
+	}
+	scanner := bufio.NewScanner(c.Reader)
+	for {
+		fmt.Printf("%s [%s]%s: ", ir.Description, ir.Name, hint)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("no value entered for input %s", ir.Name)
+		}
+		value := scanner.Text()
+		if value == "" {
+			value = ir.DefaultValue
+		}
+		if err := ir.ValidateValue(value); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return 0
+}
+
 func (c CliCallbacks) OpenUrl(url string) error {
 	return browser.OpenURL(url)
 }
 
 func (c CliCallbacks) Confirm(msg string, args ...interface{}) (bool, error) {
+	if !c.interactive() {
+		return false, fmt.Errorf("cannot confirm in non-interactive mode: %s", fmt.Sprintf(msg, args...))
+	}
 	prompt := promptui.Select{
 		Label: fmt.Sprintf(msg, args...),
 		Items: []string{"yes", "no"},
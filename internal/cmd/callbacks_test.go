@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonInteractiveRequestInput(t *testing.T) {
+	nonInteractive := true
+	c := CliCallbacks{NonInteractive: &nonInteractive}
+	tests := map[string]struct {
+		request api.InputRequest
+		value   string
+		errMsg  string
+	}{
+		"default value": {
+			request: api.InputRequest{Name: "env", Type: api.Select, Values: []string{"dev", "prod"}, DefaultValue: "prod"},
+			value:   "prod",
+		},
+		"optional text": {
+			request: api.InputRequest{Name: "message", Type: api.Text},
+			value:   "",
+		},
+		"optional multiselect": {
+			request: api.InputRequest{Name: "labels", Type: api.MultiSelect, Values: []string{"bug", "feature"}},
+			value:   "",
+		},
+		"required text": {
+			request: api.InputRequest{Name: "title", Type: api.Text, Required: true},
+			errMsg:  "input title is required but cannot be requested in non-interactive mode",
+		},
+		"select without default": {
+			request: api.InputRequest{Name: "env", Type: api.Select, Values: []string{"dev", "prod"}},
+			errMsg:  "input env is required but cannot be requested in non-interactive mode",
+		},
+	}
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		inputs, err := c.RequestInput([]api.InputRequest{test.request})
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.value, inputs[test.request.Name])
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestNonInteractiveConfirm(t *testing.T) {
+	nonInteractive := true
+	c := CliCallbacks{NonInteractive: &nonInteractive}
+	confirmed, err := c.Confirm("Delete %s?", "release/v1.0")
+	assert.False(t, confirmed)
+	assert.Equal(t, "cannot confirm in non-interactive mode: Delete release/v1.0?", fmt.Sprint(err))
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func createConfigCommand(de *exec.DredgeExec) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of the Dredgefile",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "view",
+		Short: "Show the merged Dredgefile and the file each value comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return viewConfig(de, cmd.OutOrStdout())
+		},
+	})
+	return configCmd
+}
+
+func viewConfig(de *exec.DredgeExec, out io.Writer) error {
+	var names []string
+	for _, layer := range de.Layers {
+		names = append(names, layer.Name)
+	}
+	fmt.Fprintf(out, "# Merged from %s\n", strings.Join(names, ", "))
+
+	var node yaml.Node
+	if err := node.Encode(de.DredgeFile); err != nil {
+		return err
+	}
+	addOriginComments(&node, de.Origins)
+
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// addOriginComments adds the origin of the values as comments, to the keys of
+// the sections that are maps and to the names in the sections that are lists.
+func addOriginComments(node *yaml.Node, origins config.Origins) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		section := node.Content[i].Value
+		value := node.Content[i+1]
+		switch value.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if origin, ok := origins[section+"."+value.Content[j].Value]; ok {
+					value.Content[j].LineComment = origin
+				}
+			}
+		case yaml.SequenceNode:
+			if origin, ok := origins[section]; ok {
+				node.Content[i].LineComment = origin
+			}
+			for _, item := range value.Content {
+				for j := 0; j+1 < len(item.Content); j += 2 {
+					if item.Content[j].Value != "name" {
+						continue
+					}
+					if origin, ok := origins[section+"."+item.Content[j+1].Value]; ok {
+						item.Content[j+1].LineComment = origin
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewConfig(t *testing.T) {
+	base := &config.DredgeFile{
+		Variables: config.Variables{"PORT": "8080", "IMAGE": "app:latest"},
+		Workflows: []config.Workflow{{Name: "build"}},
+		Resources: config.Resources{"issue": {{Provider: "jira", Config: map[string]string{"project": "DRG"}}}},
+	}
+	local := &config.DredgeFile{Variables: config.Variables{"PORT": "9090"}}
+
+	e := exec.EmptyExec("./Dredgefile", nil, nil)
+	e.Layers = []exec.Layer{
+		{Name: "./Dredgefile", DredgeFile: base},
+		{Name: "./Dredgefile.local", DredgeFile: local},
+	}
+	e.Origins = config.Origins{}
+	base.AddOrigins("./Dredgefile", e.Origins)
+	e.DredgeFile = base.Merge(local, "./Dredgefile.local", e.Origins)
+
+	var out bytes.Buffer
+	assert.Nil(t, viewConfig(e, &out))
+	assert.Equal(t, `# Merged from ./Dredgefile, ./Dredgefile.local
+variables:
+  IMAGE: app:latest # ./Dredgefile
+  PORT: "9090" # ./Dredgefile.local
+workflows:
+  - name: build # ./Dredgefile
+resources:
+  issue: # ./Dredgefile
+    - provider: jira
+      config:
+        project: DRG
+`, out.String())
+}
+
+func TestGetProfile(t *testing.T) {
+	t.Setenv(exec.ProfileEnv, "dev")
+
+	tests := map[string]struct {
+		args    []string
+		profile string
+	}{
+		"flag": {
+			args:    []string{"build", "--profile", "prod"},
+			profile: "prod",
+		},
+		"flag with value": {
+			args:    []string{"--profile=staging", "build"},
+			profile: "staging",
+		},
+		"env": {
+			args:    []string{"build"},
+			profile: "dev",
+		},
+		"after --": {
+			args:    []string{"run", "--", "--profile", "prod"},
+			profile: "dev",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		assert.Equal(t, test.profile, GetProfile(test.args))
+	}
+}
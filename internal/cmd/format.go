@@ -1,110 +1,393 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/exec"
 	"github.com/fatih/color"
 	"github.com/rodaine/table"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	OUTPUT_TABLE    = "table"
+	OUTPUT_JSON     = "json"
+	OUTPUT_YAML     = "yaml"
+	OUTPUT_CSV      = "csv"
+	OUTPUT_TSV      = "tsv"
+	OUTPUT_TEMPLATE = "template"
+)
+
+var OUTPUT_FORMATS = []string{OUTPUT_TABLE, OUTPUT_JSON, OUTPUT_YAML, OUTPUT_CSV, OUTPUT_TSV, OUTPUT_TEMPLATE + "=<template>"}
+
 func init() {
 	table.DefaultHeaderFormatter = color.New(color.Bold).SprintfFunc()
 }
 
-func format(output *api.CommandOutput) (string, error) {
+type outputOptions struct {
+	format    string
+	template  *template.Template
+	noHeaders bool
+}
+
+func parseOutputOptions(output string, noHeaders bool) (*outputOptions, error) {
+	opts := &outputOptions{
+		format:    output,
+		noHeaders: noHeaders,
+	}
+	switch output {
+	case "", OUTPUT_TABLE:
+		opts.format = OUTPUT_TABLE
+	case OUTPUT_JSON, OUTPUT_YAML, OUTPUT_CSV, OUTPUT_TSV:
+	default:
+		if !strings.HasPrefix(output, OUTPUT_TEMPLATE+"=") {
+			return nil, fmt.Errorf("unknown output format %s (valid options are: %s)", output, strings.Join(OUTPUT_FORMATS, ", "))
+		}
+		t, err := template.New("").Funcs(exec.TEMPLATE_FUNCTIONS).Parse(strings.TrimPrefix(output, OUTPUT_TEMPLATE+"="))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse output template: %s", err)
+		}
+		opts.format = OUTPUT_TEMPLATE
+		opts.template = t
+	}
+	return opts, nil
+}
+
+func format(output *api.CommandOutput, opts *outputOptions) (string, error) {
+	if opts == nil {
+		opts = &outputOptions{format: OUTPUT_TABLE}
+	}
+
+	if isScalarType(output.Type) {
+		return formatScalars(output, opts)
+	}
+
+	columns, records, err := getRecords(output)
+	if err != nil {
+		return "", err
+	}
+
+	switch opts.format {
+	case OUTPUT_JSON:
+		return formatJson(records, output.Type.IsArray)
+	case OUTPUT_YAML:
+		return formatYaml(records, output.Type.IsArray)
+	case OUTPUT_CSV:
+		return formatCsv(columns, records, ',', opts.noHeaders)
+	case OUTPUT_TSV:
+		return formatCsv(columns, records, '\t', opts.noHeaders)
+	case OUTPUT_TEMPLATE:
+		return formatTemplate(opts.template, records)
+	}
+
 	if output.Type.Name == "object" {
-		return formatPlain(output.Type.IsArray, output.Output)
+		return formatPlain(records, opts.noHeaders)
 	}
+	return formatTable(columns, records, opts.noHeaders), nil
+}
 
-	out := new(strings.Builder)
-	tbl := table.New(formatHeader(output.Type)...).WithWriter(out)
+// record is an object with a fixed field order, so the fields are rendered in
+// the order of the type definition in every output format.
+type record struct {
+	fields []string
+	values map[string]interface{}
+}
 
+func (r record) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	for i, f := range r.fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r.values[f])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func (r record) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range r.fields {
+		value := &yaml.Node{}
+		if err := value.Encode(r.values[f]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f}, value)
+	}
+	return node, nil
+}
+
+func (r record) strings() []string {
+	var output []string
+	for _, f := range r.fields {
+		output = append(output, formatValue(r.values[f]))
+	}
+	return output
+}
+
+func (r record) tableStrings() []string {
+	var output []string
+	for _, f := range r.fields {
+		if _, ok := r.values[f]; !ok {
+			output = append(output, "<empty>")
+		} else {
+			output = append(output, formatValue(r.values[f]))
+		}
+	}
+	return output
+}
+
+func getRecords(output *api.CommandOutput) ([]string, []record, error) {
+	var objects []interface{}
 	if output.Type.IsArray {
 		s := reflect.ValueOf(output.Output)
 		if s.Kind() != reflect.Slice {
-			return "", fmt.Errorf("expected array type but provider returned object")
+			return nil, nil, fmt.Errorf("expected array type but provider returned object")
 		}
 		for i := 0; i < s.Len(); i++ {
-			row, err := formatObject(output.Type, s.Index(i).Interface())
-			if err != nil {
-				return "", err
-			}
-			tbl.AddRow(row...)
+			objects = append(objects, s.Index(i).Interface())
 		}
 	} else {
-		row, err := formatObject(output.Type, output.Output)
-		if err != nil {
-			return "", err
+		objects = append(objects, output.Output)
+	}
+
+	var columns []string
+	var records []record
+	for _, o := range objects {
+		s := reflect.ValueOf(o)
+		if s.Kind() != reflect.Map {
+			return nil, nil, fmt.Errorf("provider did not return a proper object")
+		}
+		fields := getFieldNames(output.Type, s)
+		values := make(map[string]interface{})
+		for _, f := range fields {
+			val := s.MapIndex(reflect.ValueOf(f))
+			if val.IsValid() {
+				values[f] = val.Interface()
+			}
 		}
-		tbl.AddRow(row...)
+		records = append(records, record{fields: fields, values: values})
+		columns = mergeColumns(columns, fields)
+	}
+	if len(objects) == 0 {
+		columns = getFieldNames(output.Type, reflect.Value{})
 	}
+	return columns, records, nil
+}
 
-	tbl.Print()
-	return out.String(), nil
+func getFieldNames(t *api.Type, o reflect.Value) []string {
+	var fields []string
+	if len(t.Fields) > 0 || !o.IsValid() {
+		for _, f := range t.Fields {
+			fields = append(fields, f.Name)
+		}
+		return fields
+	}
+	for _, key := range o.MapKeys() {
+		fields = append(fields, fmt.Sprint(key.Interface()))
+	}
+	sort.Strings(fields)
+	return fields
 }
 
-func formatPlain(isArray bool, o interface{}) (string, error) {
-	if isArray {
-		var formatted string
+func mergeColumns(columns, fields []string) []string {
+	for _, f := range fields {
+		found := false
+		for _, c := range columns {
+			if c == f {
+				found = true
+				break
+			}
+		}
+		if !found {
+			columns = append(columns, f)
+		}
+	}
+	return columns
+}
 
-		s := reflect.ValueOf(o)
+func isScalarType(t *api.Type) bool {
+	return t.Name == "string" || t.Name == "date"
+}
+
+func formatScalars(output *api.CommandOutput, opts *outputOptions) (string, error) {
+	switch opts.format {
+	case OUTPUT_JSON:
+		return marshalJson(output.Output)
+	case OUTPUT_YAML:
+		return marshalYaml(output.Output)
+	}
+
+	var values []interface{}
+	if output.Type.IsArray {
+		s := reflect.ValueOf(output.Output)
 		if s.Kind() != reflect.Slice {
 			return "", fmt.Errorf("expected array type but provider returned object")
 		}
 		for i := 0; i < s.Len(); i++ {
-			line, err := formatPlainObject(s.Index(i).Interface())
-			if err != nil {
-				return "", err
+			values = append(values, s.Index(i).Interface())
+		}
+	} else {
+		values = append(values, output.Output)
+	}
+
+	out := new(strings.Builder)
+	for _, v := range values {
+		if opts.format == OUTPUT_TEMPLATE {
+			if err := opts.template.Execute(out, v); err != nil {
+				return "", fmt.Errorf("failed to execute output template: %s", err)
 			}
-			formatted = formatted + "\n" + line
+			out.WriteString("\n")
+		} else {
+			out.WriteString(formatValue(v) + "\n")
 		}
-		return formatted + "\n", nil
 	}
-	return formatPlainObject(o)
+	return out.String(), nil
 }
 
-func formatPlainObject(o interface{}) (string, error) {
-	s := reflect.ValueOf(o)
-	if s.Kind() != reflect.Map {
-		return "", fmt.Errorf("provider did not return a proper object")
+func formatJson(records []record, isArray bool) (string, error) {
+	if isArray {
+		if records == nil {
+			records = []record{}
+		}
+		return marshalJson(records)
 	}
+	return marshalJson(records[0])
+}
 
+func marshalJson(o interface{}) (string, error) {
+	b, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+func formatYaml(records []record, isArray bool) (string, error) {
+	if isArray {
+		if records == nil {
+			records = []record{}
+		}
+		return marshalYaml(records)
+	}
+	return marshalYaml(records[0])
+}
+
+func marshalYaml(o interface{}) (string, error) {
+	b, err := yaml.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func formatCsv(columns []string, records []record, separator rune, noHeaders bool) (string, error) {
 	out := new(strings.Builder)
-	tbl := table.New("Field", "Value").WithWriter(out)
-	for _, key := range s.MapKeys() {
-		tbl.AddRow(key.String(), formatField(s.MapIndex(key)))
+	w := csv.NewWriter(out)
+	w.Comma = separator
+	if !noHeaders {
+		if err := w.Write(columns); err != nil {
+			return "", err
+		}
+	}
+	for _, r := range records {
+		row := record{fields: columns, values: r.values}
+		if err := w.Write(row.strings()); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return out.String(), w.Error()
+}
+
+func formatTemplate(t *template.Template, records []record) (string, error) {
+	out := new(strings.Builder)
+	for _, r := range records {
+		values := make(map[string]interface{})
+		for _, f := range r.fields {
+			values[f] = r.values[f]
+		}
+		if err := t.Execute(out, values); err != nil {
+			return "", fmt.Errorf("failed to execute output template: %s", err)
+		}
+		out.WriteString("\n")
 	}
-	tbl.Print()
 	return out.String(), nil
 }
 
-func formatHeader(t *api.Type) []interface{} {
-	var output []interface{}
-	for _, f := range t.Fields {
-		output = append(output, f.Name)
+func formatTable(columns []string, records []record, noHeaders bool) string {
+	out := new(strings.Builder)
+	tbl := newTable(noHeaders, columns...).WithWriter(out)
+	for _, r := range records {
+		row := record{fields: columns, values: r.values}
+		tbl.AddRow(toInterfaces(row.tableStrings())...)
 	}
-	return output
+	tbl.Print()
+	return out.String()
+}
+
+func formatPlain(records []record, noHeaders bool) (string, error) {
+	var formatted []string
+	for _, r := range records {
+		out := new(strings.Builder)
+		tbl := newTable(noHeaders, "Field", "Value").WithWriter(out)
+		for i, value := range r.tableStrings() {
+			tbl.AddRow(r.fields[i], value)
+		}
+		tbl.Print()
+		formatted = append(formatted, out.String())
+	}
+	return strings.Join(formatted, "\n"), nil
 }
 
-func formatObject(t *api.Type, o interface{}) ([]interface{}, error) {
-	s := reflect.ValueOf(o)
-	if s.Kind() != reflect.Map {
-		return nil, fmt.Errorf("provider did not return a proper object")
+func newTable(noHeaders bool, columns ...string) table.Table {
+	var header []interface{}
+	for _, c := range columns {
+		if noHeaders {
+			header = append(header, "")
+		} else {
+			header = append(header, c)
+		}
+	}
+	tbl := table.New(header...)
+	if noHeaders {
+		tbl.WithHeaderFormatter(func(string, ...interface{}) string {
+			return ""
+		})
 	}
+	return tbl
+}
+
+func toInterfaces(values []string) []interface{} {
 	var output []interface{}
-	for _, f := range t.Fields {
-		val := s.MapIndex(reflect.ValueOf(f.Name))
-		output = append(output, formatField(val))
+	for _, v := range values {
+		output = append(output, v)
 	}
-	return output, nil
+	return output
 }
 
-func formatField(o reflect.Value) string {
-	if !o.IsValid() {
-		return "<empty>"
+func formatValue(o interface{}) string {
+	if o == nil {
+		return ""
 	}
-	return fmt.Sprintf("%s", o)
+	return fmt.Sprintf("%v", o)
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	releaseType := &api.Type{
+		Name:    "release",
+		IsArray: true,
+		Fields: []api.Field{
+			{Name: "name", Type: "string"},
+			{Name: "title", Type: "string"},
+			{Name: "date", Type: "date"},
+		},
+	}
+	releases := []map[string]interface{}{
+		{"title": "First release", "name": "v1", "date": "2022-01-01"},
+		{"title": "Second, better", "name": "v2"},
+	}
+
+	tests := map[string]struct {
+		output    *api.CommandOutput
+		format    string
+		noHeaders bool
+		expected  string
+		errMsg    string
+	}{
+		"json": {
+			output: &api.CommandOutput{Type: releaseType, Output: releases},
+			format: "json",
+			expected: `[
+  {
+    "name": "v1",
+    "title": "First release",
+    "date": "2022-01-01"
+  },
+  {
+    "name": "v2",
+    "title": "Second, better",
+    "date": null
+  }
+]
+`,
+		},
+		"json object": {
+			output: &api.CommandOutput{Type: &api.Type{Name: "object"}, Output: map[string]interface{}{"b": 1, "a": "x"}},
+			format: "json",
+			expected: `{
+  "a": "x",
+  "b": 1
+}
+`,
+		},
+		"json empty array": {
+			output:   &api.CommandOutput{Type: releaseType, Output: []map[string]interface{}{}},
+			format:   "json",
+			expected: "[]\n",
+		},
+		"yaml": {
+			output: &api.CommandOutput{Type: releaseType, Output: releases},
+			format: "yaml",
+			expected: `- name: v1
+  title: First release
+  date: "2022-01-01"
+- name: v2
+  title: Second, better
+  date: null
+`,
+		},
+		"csv": {
+			output:   &api.CommandOutput{Type: releaseType, Output: releases},
+			format:   "csv",
+			expected: "name,title,date\nv1,First release,2022-01-01\nv2,\"Second, better\",\n",
+		},
+		"tsv without headers": {
+			output:    &api.CommandOutput{Type: releaseType, Output: releases},
+			format:    "tsv",
+			noHeaders: true,
+			expected:  "v1\tFirst release\t2022-01-01\nv2\tSecond, better\t\n",
+		},
+		"template": {
+			output:   &api.CommandOutput{Type: releaseType, Output: releases},
+			format:   `template={{ .name }}: {{ replace .title " " "-" }}`,
+			expected: "v1: First-release\nv2: Second,-better\n",
+		},
+		"string array": {
+			output:   &api.CommandOutput{Type: &api.Type{Name: "string", IsArray: true}, Output: []string{"a", "b"}},
+			format:   "table",
+			expected: "a\nb\n",
+		},
+		"table without headers": {
+			output:    &api.CommandOutput{Type: releaseType, Output: releases},
+			format:    "table",
+			noHeaders: true,
+			expected:  "v1  First release   2022-01-01  \nv2  Second, better  <empty>     \n",
+		},
+		"unknown format": {
+			output: &api.CommandOutput{Type: releaseType, Output: releases},
+			format: "xml",
+			errMsg: "unknown output format xml (valid options are: table, json, yaml, csv, tsv, template=<template>)",
+		},
+		"invalid template": {
+			output: &api.CommandOutput{Type: releaseType, Output: releases},
+			format: "template={{ .name",
+			errMsg: "failed to parse output template: template: :1: unclosed action",
+		},
+		"not an array": {
+			output: &api.CommandOutput{Type: releaseType, Output: releases[0]},
+			format: "json",
+			errMsg: "expected array type but provider returned object",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		opts, err := parseOutputOptions(test.format, test.noHeaders)
+		var output string
+		if err == nil {
+			output, err = format(test.output, opts)
+		}
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, output)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/spf13/cobra"
+)
+
+func createUpdateImportsCommand(de *exec.DredgeExec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "update-imports",
+		Short: "Update the commits of the remote imports pinned in " + exec.LockFileName,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			updates, err := de.UpdateImports()
+			if err != nil {
+				return err
+			}
+			if len(updates) == 0 {
+				return de.Log(api.Info, "All imports are up to date")
+			}
+			for _, u := range updates {
+				if u.From == "" {
+					err = de.Log(api.Info, "Pinned %s to %s", u.Import, u.To)
+				} else {
+					err = de.Log(api.Info, "Updated %s from %s to %s", u.Import, u.From, u.To)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
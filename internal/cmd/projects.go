@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/dredge-dev/dredge/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+var All bool
+
+// addProjectsCommands adds the workflows and buckets of the projects as
+// <project>:<name> and the --all flag to run a workflow in all projects.
+func addProjectsCommands(e *exec.DredgeExec, rootCmd *cobra.Command) error {
+	projects, err := e.GetProjects()
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+
+	rootCmd.AddGroup(&cobra.Group{
+		ID:    "project",
+		Title: "Project Commands:",
+	})
+	for _, p := range projects {
+		workflows, err := p.Exec.GetWorkflows()
+		if err != nil {
+			return fmt.Errorf("project %s: %v", p.Name, err)
+		}
+		for _, w := range workflows {
+			subCmd, err := createWorkflowCommand(w)
+			if err != nil {
+				return err
+			}
+			subCmd.Use = fmt.Sprintf("%s:%s", p.Name, w.Name)
+			subCmd.GroupID = "project"
+			rootCmd.AddCommand(subCmd)
+		}
+		buckets, err := p.Exec.GetBuckets()
+		if err != nil {
+			return fmt.Errorf("project %s: %v", p.Name, err)
+		}
+		for _, b := range buckets {
+			subCmd, err := createBucketCommand(p.Exec, b)
+			if err != nil {
+				return err
+			}
+			subCmd.Use = fmt.Sprintf("%s:%s", p.Name, b.Name)
+			subCmd.GroupID = "project"
+			rootCmd.AddCommand(subCmd)
+		}
+	}
+
+	rootCmd.PersistentFlags().BoolVar(&All, "all", false, "Run the workflow in the Dredgefile and in all projects that define it")
+	// Workflows that are only defined in projects are not a command of the
+	// root, so the root command runs them with --all
+	rootCmd.Args = cobra.ArbitraryArgs
+	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+		if !All || len(args) != 1 {
+			return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+		}
+		return runInAllProjects(e, projects, args[0])
+	}
+	for _, subCmd := range rootCmd.Commands() {
+		if subCmd.GroupID != "workflow" || subCmd.RunE == nil {
+			continue
+		}
+		name, runE := subCmd.Name(), subCmd.RunE
+		subCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if All {
+				return runInAllProjects(e, projects, name)
+			}
+			return runE(cmd, args)
+		}
+	}
+	return nil
+}
+
+// runInAllProjects runs the workflow of the Dredgefile, followed by the
+// workflow in every project that defines it.
+func runInAllProjects(e *exec.DredgeExec, projects []*exec.Project, name string) error {
+	type projectWorkflow struct {
+		project  string
+		workflow *workflow.Workflow
+	}
+	var workflows []projectWorkflow
+	if w, err := e.GetWorkflow("", name); err == nil {
+		workflows = append(workflows, projectWorkflow{"root", w})
+	}
+	for _, p := range projects {
+		if w, err := p.Exec.GetWorkflow("", name); err == nil {
+			workflows = append(workflows, projectWorkflow{p.Name, w})
+		}
+	}
+	if len(workflows) == 0 {
+		return fmt.Errorf("could not find workflow %s in the Dredgefile or its projects", name)
+	}
+	for _, pw := range workflows {
+		if err := e.Log(api.Info, "Running %s in %s", name, pw.project); err != nil {
+			return err
+		}
+		if err := pw.workflow.Execute(); err != nil {
+			return fmt.Errorf("project %s: %v", pw.project, err)
+		}
+	}
+	return nil
+}
@@ -2,13 +2,28 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/dredge-dev/dredge/internal/resource"
 	"github.com/spf13/cobra"
 )
 
-var textParam string
+// inputFlagsAnnotation lists the flags of a command that are generated from
+// the inputs in the resource definitions.
+const inputFlagsAnnotation = "dredge_input_flags"
+
+var RESOURCE_COMMANDS = []string{"get", "create", "describe", "update", "search", "delete"}
+
+var outputParam string
+var noHeadersParam bool
+var filterParams []string
+var sortByParam string
+var limitParam int
+var columnsParam string
 
 type ArgsParser func(args []string) (string, map[string]string, error)
 
@@ -21,49 +36,225 @@ func GetResourcesHelp(e *exec.DredgeExec) string {
 }
 
 func addResourceCommands(e *exec.DredgeExec, rootCmd *cobra.Command) error {
-	rootCmd.AddCommand(&cobra.Command{
-		Use:     "get <resource>",
-		Short:   "Get all resources of the provided type",
-		GroupID: "resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return printOrErr(runResourceCommand("get", args, ResourceArgsParser, e))
-		},
-	})
-	rootCmd.AddCommand(&cobra.Command{
-		Use:     "create <resource>",
-		Short:   "Create a resource of the provided type",
-		GroupID: "resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return printOrErr(runResourceCommand("create", args, ResourceArgsParser, e))
-		},
-	})
-	rootCmd.AddCommand(&cobra.Command{
-		Use:     "describe <resource>/<name>",
-		Short:   "Describe a resource with the provided type and name",
+	getCmd := createResourceCommand(e, "get", "get <resource>", "Get all resources of the provided type", ResourceArgsParser)
+	getCmd.Flags().StringArrayVar(&filterParams, "filter", nil, "filter on a field (<field>=<value>, !=, ~= for a regular expression, <, <=, >, >= for date fields)")
+	getCmd.Flags().StringVar(&sortByParam, "sort-by", "", "field to sort on, prefix with - to sort descending")
+	getCmd.Flags().IntVar(&limitParam, "limit", 0, "maximum number of results")
+	getCmd.Flags().StringVar(&columnsParam, "columns", "", "comma separated list of fields to show")
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(createResourceCommand(e, "create", "create <resource>", "Create a resource of the provided type", ResourceArgsParser))
+	rootCmd.AddCommand(createResourceCommand(e, "describe", "describe <resource>/<name>", "Describe a resource with the provided type and name", ResourceAndNameArgsParser))
+	rootCmd.AddCommand(createResourceCommand(e, "update", "update <resource>/<name>", "Update a resource with the provided type and name", ResourceAndNameArgsParser))
+	rootCmd.AddCommand(createResourceCommand(e, "search", "search <resource>", "Search for a resource of the provided type", ResourceArgsParser))
+	rootCmd.AddCommand(createResourceCommand(e, "delete", "delete <resource>/<name>", "Delete a resource with the provided type and name", ResourceAndNameArgsParser))
+
+	for _, command := range getCustomCommands(e) {
+		if isCommandDefined(rootCmd, command) {
+			e.Log(api.Debug, "Skipping the %s command for resources, a command with the same name exists", command)
+			continue
+		}
+		use := command + " <resource>"
+		argsParser := ResourceArgsParser
+		if hasNameInput(e, command) {
+			use = command + " <resource>/<name>"
+			argsParser = ResourceAndNameArgsParser
+		}
+		rootCmd.AddCommand(createResourceCommand(e, command, use, fmt.Sprintf("Execute the %s command of a resource", command), argsParser))
+	}
+	return nil
+}
+
+// getCustomCommands returns the commands in the resource definitions of the
+// configured resources that don't have a built-in command.
+func getCustomCommands(e *exec.DredgeExec) []string {
+	var commands []string
+	found := make(map[string]bool)
+	for _, rd := range getConfiguredResourceDefinitions(e) {
+		for _, c := range rd.Commands {
+			if found[c.Name] || contains(RESOURCE_COMMANDS, c.Name) {
+				continue
+			}
+			found[c.Name] = true
+			commands = append(commands, c.Name)
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+func isCommandDefined(rootCmd *cobra.Command, name string) bool {
+	if name == "help" || name == "completion" {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNameInput checks if the command has a name input for any of the
+// configured resources, in that case the name is passed as <resource>/<name>.
+func hasNameInput(e *exec.DredgeExec, command string) bool {
+	for _, rd := range getConfiguredResourceDefinitions(e) {
+		if c, err := rd.GetCommand(command); err == nil {
+			if _, ok := c.GetInput("name"); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func createResourceCommand(e *exec.DredgeExec, command, use, short string, argsParser ArgsParser) *cobra.Command {
+	withName := strings.HasSuffix(use, "/<name>")
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
 		GroupID: "resource",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return printOrErr(runResourceCommand("describe", args, ResourceAndNameArgsParser, e))
+			return printOrErr(runResourceCommand(cmd, command, args, argsParser, e))
 		},
-	})
-	rootCmd.AddCommand(&cobra.Command{
-		Use:     "update <resource>/<name>",
-		Short:   "Update a resource with the provided type and name",
-		GroupID: "resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return printOrErr(runResourceCommand("update", args, ResourceAndNameArgsParser, e))
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var resources []string
+			for _, rd := range getConfiguredResourceDefinitions(e) {
+				if _, err := rd.GetCommand(command); err == nil {
+					if withName {
+						resources = append(resources, rd.Name+"/")
+					} else {
+						resources = append(resources, rd.Name)
+					}
+				}
+			}
+			if withName {
+				return resources, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+			}
+			return resources, cobra.ShellCompDirectiveNoFileComp
 		},
+	}
+	addOutputFlags(cmd)
+	addInputFlags(e, cmd, command, withName)
+	return cmd
+}
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputParam, "output", "o", OUTPUT_TABLE, fmt.Sprintf("output format (%s)", strings.Join(OUTPUT_FORMATS, ", ")))
+	cmd.Flags().BoolVar(&noHeadersParam, "no-headers", false, "do not print headers in table, csv and tsv output")
+}
+
+// addInputFlags adds a flag for every input of the command in the resource
+// definitions of the configured resources. The name input is skipped when it
+// is passed as <resource>/<name>.
+func addInputFlags(e *exec.DredgeExec, cmd *cobra.Command, command string, withName bool) {
+	for _, rd := range getConfiguredResourceDefinitions(e) {
+		c, err := rd.GetCommand(command)
+		if err != nil {
+			continue
+		}
+		for _, input := range c.Inputs {
+			if (withName && input.Name == "name") || cmd.Flags().Lookup(input.Name) != nil {
+				continue
+			}
+			name := input.Name
+			cmd.Flags().String(name, "", inputUsage(input.Description, input.Values))
+			if cmd.Annotations == nil {
+				cmd.Annotations = make(map[string]string)
+			}
+			cmd.Annotations[inputFlagsAnnotation] = strings.TrimPrefix(cmd.Annotations[inputFlagsAnnotation]+","+name, ",")
+			cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return getInputValues(e, command, name, args), cobra.ShellCompDirectiveNoFileComp
+			})
+		}
+	}
+}
+
+func getConfiguredResourceDefinitions(e *exec.DredgeExec) []api.ResourceDefinition {
+	var definitions []api.ResourceDefinition
+	for _, rd := range e.ResourceDefinitions {
+		if _, ok := e.DredgeFile.Resources[rd.Name]; ok {
+			definitions = append(definitions, rd)
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
 	})
-	searchCmd := &cobra.Command{
-		Use:     "search <resource>",
-		Short:   "Search for a resource of the provided type",
-		GroupID: "resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return printOrErr(runResourceCommand("search", args, ResourceAndTextArgsParser, e))
-		},
+	return definitions
+}
+
+// getInputValues returns the values of a select input, for the resource in
+// args or for all resources if the resource is not known yet.
+func getInputValues(e *exec.DredgeExec, command, name string, args []string) []string {
+	var resourceName string
+	if len(args) > 0 {
+		resourceName = strings.SplitN(args[0], "/", 2)[0]
 	}
-	searchCmd.Flags().StringVar(&textParam, "text", "", "text to search")
-	rootCmd.AddCommand(searchCmd)
-	return nil
+	var values []string
+	for _, rd := range getConfiguredResourceDefinitions(e) {
+		if resourceName != "" && rd.Name != resourceName {
+			continue
+		}
+		c, err := rd.GetCommand(command)
+		if err != nil {
+			continue
+		}
+		if input, ok := c.GetInput(name); ok {
+			values = append(values, input.Values...)
+		}
+	}
+	return values
+}
+
+// getInputsFromFlags returns the values of the input flags that are set. Flags
+// that are not an input of the command for the resource are rejected and the
+// values are validated before the provider is called.
+func getInputsFromFlags(cmd *cobra.Command, e *exec.DredgeExec, resourceName, command string) (map[string]string, error) {
+	inputs := make(map[string]string)
+	var flags []string
+	for _, name := range config.SplitList(cmd.Annotations[inputFlagsAnnotation]) {
+		if cmd.Flags().Changed(name) {
+			flags = append(flags, name)
+		}
+	}
+	if len(flags) == 0 {
+		return inputs, nil
+	}
+
+	rd, err := e.GetResourceDefinition(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	c, err := rd.GetCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range flags {
+		input, ok := c.GetInput(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown flag --%s for %s %s", name, command, resourceName)
+		}
+		value, err := cmd.Flags().GetString(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := input.ValidateValue(value); err != nil {
+			return nil, err
+		}
+		inputs[name] = value
+	}
+	return inputs, nil
 }
 
 func ResourceArgsParser(args []string) (string, map[string]string, error) {
@@ -86,22 +277,32 @@ func ResourceAndNameArgsParser(args []string) (string, map[string]string, error)
 	}, nil
 }
 
-func ResourceAndTextArgsParser(args []string) (string, map[string]string, error) {
-	if len(args) < 1 {
-		return "", nil, fmt.Errorf("not enough arguments: missing <resource>")
+func runResourceCommand(cmd *cobra.Command, command string, args []string, argsParser ArgsParser, e *exec.DredgeExec) (string, error) {
+	resourceName, namedArgs, err := argsParser(args)
+	if err != nil {
+		return "", err
 	}
-	inputs := make(map[string]string)
-	if textParam != "" {
-		inputs["text"] = textParam
+
+	opts, err := parseOutputOptions(outputParam, noHeadersParam)
+	if err != nil {
+		return "", err
 	}
-	return args[0], inputs, nil
-}
 
-func runResourceCommand(command string, args []string, argsParser ArgsParser, e *exec.DredgeExec) (string, error) {
-	resourceName, namedArgs, err := argsParser(args)
+	var query *resource.Query
+	if command == "get" && hasQuery() {
+		query, err = resource.NewQuery(filterParams, sortByParam, limitParam, config.SplitList(columnsParam))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	inputs, err := getInputsFromFlags(cmd, e, resourceName, command)
 	if err != nil {
 		return "", err
 	}
+	for name, value := range inputs {
+		namedArgs[name] = value
+	}
 
 	e.Env.AddInputs(namedArgs)
 	output, err := e.ExecuteResourceCommand(resourceName, command)
@@ -109,7 +310,17 @@ func runResourceCommand(command string, args []string, argsParser ArgsParser, e
 		return "", err
 	}
 
-	return format(output)
+	if query != nil {
+		if err := query.Apply(output); err != nil {
+			return "", err
+		}
+	}
+
+	return format(output, opts)
+}
+
+func hasQuery() bool {
+	return len(filterParams) > 0 || sortByParam != "" || limitParam != 0 || columnsParam != ""
 }
 
 func printOrErr(output string, err error) error {
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/dredge-dev/dredge/internal/resource"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetInputsFromFlags(t *testing.T) {
+	e := exec.EmptyExec("./Dredgefile", resource.GetDefaultResourceDefinitions(), nil)
+	e.DredgeFile.Resources = config.Resources{
+		"issue":  config.Resource{{Provider: "github-issues"}},
+		"deploy": config.Resource{{Provider: "local-docker-compose"}},
+	}
+
+	tests := map[string]struct {
+		command  string
+		use      string
+		args     []string
+		resource string
+		inputs   map[string]string
+		errMsg   string
+	}{
+		"create issue": {
+			command:  "create",
+			use:      "create <resource>",
+			args:     []string{"--title", "Broken login", "--type", "feature"},
+			resource: "issue",
+			inputs:   map[string]string{"title": "Broken login", "type": "feature"},
+		},
+		"configured issue type": {
+			command:  "create",
+			use:      "create <resource>",
+			args:     []string{"--type", "task"},
+			resource: "issue",
+			inputs:   map[string]string{"type": "task"},
+		},
+		"invalid pattern": {
+			command:  "update",
+			use:      "update <resource>/<name>",
+			args:     []string{"--state", "done"},
+			resource: "issue",
+			errMsg:   "invalid value (done) for input state (should match ^(open|closed)$)",
+		},
+		"update deploy": {
+			command:  "update",
+			use:      "update <resource>/<name>",
+			args:     []string{"--version", "1.2", "--instances", "2"},
+			resource: "deploy",
+			inputs:   map[string]string{"version": "1.2", "instances": "2"},
+		},
+		"invalid number": {
+			command:  "update",
+			use:      "update <resource>/<name>",
+			args:     []string{"--instances", "two"},
+			resource: "deploy",
+			errMsg:   "invalid value (two) for input instances (expected a number)",
+		},
+		"flag of other resource": {
+			command:  "update",
+			use:      "update <resource>/<name>",
+			args:     []string{"--title", "x"},
+			resource: "deploy",
+			errMsg:   "unknown flag: --title",
+		},
+		"no flags": {
+			command:  "create",
+			use:      "create <resource>",
+			resource: "issue",
+			inputs:   map[string]string{},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		cmd := createResourceCommand(e, test.command, test.use, "", ResourceArgsParser)
+		err := cmd.ParseFlags(test.args)
+		var inputs map[string]string
+		if err == nil {
+			inputs, err = getInputsFromFlags(cmd, e, test.resource, test.command)
+		}
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.inputs, inputs)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestGetInputsFromFlagsOfOtherResource(t *testing.T) {
+	rd := []api.ResourceDefinition{
+		{
+			Name: "incident",
+			Commands: []api.Command{
+				{Name: "create", Inputs: []api.InputRequest{{Name: "title"}}, OutputType: "object"},
+			},
+		},
+		{
+			Name: "ticket",
+			Commands: []api.Command{
+				{Name: "create", Inputs: []api.InputRequest{{Name: "priority"}}, OutputType: "object"},
+			},
+		},
+	}
+	e := exec.EmptyExec("./Dredgefile", rd, nil)
+	e.DredgeFile.Resources = config.Resources{
+		"incident": config.Resource{{Provider: "shell"}},
+		"ticket":   config.Resource{{Provider: "shell"}},
+	}
+
+	cmd := createResourceCommand(e, "create", "create <resource>", "", ResourceArgsParser)
+	assert.Nil(t, cmd.ParseFlags([]string{"--priority", "high"}))
+
+	_, err := getInputsFromFlags(cmd, e, "incident", "create")
+	assert.Equal(t, "unknown flag --priority for create incident", fmt.Sprint(err))
+
+	inputs, err := getInputsFromFlags(cmd, e, "ticket", "create")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"priority": "high"}, inputs)
+}
+
+func TestAddResourceCommands(t *testing.T) {
+	rd := append(resource.GetDefaultResourceDefinitions(), api.ResourceDefinition{
+		Name: "service",
+		Commands: []api.Command{
+			{Name: "restart", Inputs: []api.InputRequest{{Name: "name"}}, OutputType: "string"},
+			{Name: "init", OutputType: "string"},
+			{Name: "scan", OutputType: "string"},
+		},
+	})
+	verbose := false
+	e := exec.EmptyExec("./Dredgefile", rd, CliCallbacks{Writer: ioutil.Discard, Verbose: &verbose})
+	e.DredgeFile.Resources = config.Resources{
+		"deploy":  config.Resource{{Provider: "local-docker-compose"}},
+		"service": config.Resource{{Provider: "shell"}},
+	}
+
+	root := &cobra.Command{Use: "drg"}
+	root.AddGroup(&cobra.Group{ID: "resource", Title: "Resource Commands:"})
+	root.AddCommand(&cobra.Command{Use: "init"})
+	err := addResourceCommands(e, root)
+	assert.Nil(t, err)
+
+	var uses []string
+	for _, c := range root.Commands() {
+		uses = append(uses, c.Use)
+	}
+	assert.Equal(t, []string{
+		"create <resource>",
+		"delete <resource>/<name>",
+		"describe <resource>/<name>",
+		"get <resource>",
+		"init",
+		"logs <resource>/<name>",
+		"restart <resource>/<name>",
+		"scan <resource>",
+		"search <resource>",
+		"update <resource>/<name>",
+	}, uses)
+}
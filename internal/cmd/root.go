@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
@@ -10,6 +12,8 @@ import (
 )
 
 var Verbose bool
+var NonInteractive bool
+var Profile string
 
 var rootCmd = &cobra.Command{
 	Use:   "drg",
@@ -27,7 +31,9 @@ func init() {
 		Title: "Workflow Commands:",
 	})
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Print verbose output")
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().StringVar(&Profile, "profile", "", fmt.Sprintf("Profile to merge into the Dredgefile, from Dredgefile.<profile> (default: %s)", exec.ProfileEnv))
+	rootCmd.PersistentFlags().BoolVar(&NonInteractive, "non-interactive", false, "Fail instead of prompting for input, inputs without a default value need to be passed as flags")
+	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 }
 
 func Init(de *exec.DredgeExec) error {
@@ -40,12 +46,36 @@ func Init(de *exec.DredgeExec) error {
 			return runInitCommand(de, args)
 		},
 	})
+	rootCmd.AddCommand(createCacheCommand())
+	rootCmd.AddCommand(createSecretCommand())
+	rootCmd.AddCommand(createConfigCommand(de))
+	rootCmd.AddCommand(createUpdateImportsCommand(de))
 	if err := addWorkflowsCommands(de, rootCmd); err != nil {
 		return err
 	}
+	if err := addProjectsCommands(de, rootCmd); err != nil {
+		return err
+	}
 	return addResourceCommands(de, rootCmd)
 }
 
+// GetProfile returns the profile from the --profile flag or DRG_PROFILE. The
+// profile is needed to read the Dredgefile, before the flags are parsed.
+func GetProfile(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--profile" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--profile=") {
+			return strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+	return os.Getenv(exec.ProfileEnv)
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -71,7 +101,7 @@ func runInitCommand(de *exec.DredgeExec, args []string) error {
 			},
 		},
 	})
-	err := config.WriteDredgeFile(de.DredgeFile, de.Source)
+	err := de.WriteDredgeFile()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dredge-dev/dredge/internal/secrets"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func createSecretCommand() *cobra.Command {
+	var keyFile, output string
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage encrypted secret files",
+	}
+	secretCmd.PersistentFlags().StringVar(&keyFile, "key-file", "", fmt.Sprintf("Key to encrypt and decrypt with (default: %s or ~/.dredge/secret.key)", secrets.KEY_ENV))
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt <file>",
+		Short: "Encrypt a yaml file with secrets, the key is created when it doesn't exist",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				output = args[0] + ".enc"
+			}
+			return encryptSecrets(args[0], output, keyFile)
+		},
+	}
+	encryptCmd.Flags().StringVarP(&output, "output", "o", "", "File to write the encrypted secrets to (default: <file>.enc)")
+	secretCmd.AddCommand(encryptCmd)
+
+	secretCmd.AddCommand(&cobra.Command{
+		Use:   "decrypt <file>",
+		Short: "Print the secrets in an encrypted file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := secrets.ReadKey(keyFile)
+			if err != nil {
+				return err
+			}
+			plaintext, err := secrets.ReadEncryptedFile(args[0], key)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(plaintext)
+			return err
+		},
+	})
+	return secretCmd
+}
+
+func encryptSecrets(input, output, keyFile string) error {
+	plaintext, err := ioutil.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return fmt.Errorf("could not parse %s (expected a map of names to values): %v", input, err)
+	}
+	key, err := secrets.ReadOrCreateKey(keyFile)
+	if err != nil {
+		return err
+	}
+	encrypted, err := secrets.Encrypt(plaintext, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, encrypted, 0644)
+}
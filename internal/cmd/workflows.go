@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/dredge-dev/dredge/internal/exec"
 	"github.com/dredge-dev/dredge/internal/workflow"
@@ -48,15 +50,64 @@ func addWorkflows(e *exec.DredgeExec, cmd *cobra.Command) error {
 }
 
 func createWorkflowCommand(w *workflow.Workflow) (*cobra.Command, error) {
-	return &cobra.Command{
+	command := &cobra.Command{
 		Use:     w.Name,
 		Short:   w.Description,
 		Long:    w.Description,
 		GroupID: "workflow",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			only, err := cmd.Flags().GetBool("only")
+			if err != nil {
+				return err
+			}
+			w.Force, err = cmd.Flags().GetBool("force")
+			if err != nil {
+				return err
+			}
+			if err := setInputsFromFlags(cmd, w); err != nil {
+				return err
+			}
+			if only {
+				return w.ExecuteOnly()
+			}
 			return w.Execute()
 		},
-	}, nil
+	}
+	command.Flags().Bool("only", false, "Skip the workflows in needs")
+	command.Flags().Bool("force", false, "Run shell steps with sources, even if nothing changed")
+	for _, input := range w.Inputs {
+		if command.Flags().Lookup(input.Name) != nil {
+			continue
+		}
+		command.Flags().String(input.Name, "", inputUsage(input.Description, input.Values))
+	}
+	return command, nil
+}
+
+func inputUsage(description string, values []string) string {
+	if len(values) == 0 {
+		return description
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s (%s)", description, strings.Join(values, ", ")))
+}
+
+func setInputsFromFlags(cmd *cobra.Command, w *workflow.Workflow) error {
+	for _, input := range w.Inputs {
+		// Inputs named after a built-in flag are rejected by the validation
+		// of the Dredgefile and don't have a flag
+		flag := cmd.Flags().Lookup(input.Name)
+		if flag == nil || flag.Value.Type() != "string" || !flag.Changed {
+			continue
+		}
+		value := flag.Value.String()
+		if err := api.NewInputRequest(input).ValidateValue(value); err != nil {
+			return err
+		}
+		if err := w.Callbacks.SetEnv(input.Name, value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func createBucketCommand(e *exec.DredgeExec, b *workflow.Bucket) (*cobra.Command, error) {
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/exec"
+	"github.com/dredge-dev/dredge/internal/workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowInputFlags(t *testing.T) {
+	nonInteractive := true
+	tests := map[string]struct {
+		args   []string
+		inputs map[string]string
+		force  bool
+		errMsg string
+	}{
+		"inputs from flags": {
+			args:   []string{"--name", "api", "--env", "prod"},
+			inputs: map[string]string{"name": "api", "env": "prod", "force": "no"},
+		},
+		"invalid select value": {
+			args:   []string{"--name", "api", "--env", "qa"},
+			errMsg: "invalid value (qa) for input env (valid options are: dev, prod)",
+		},
+		"missing input": {
+			args:   []string{"--env", "dev"},
+			errMsg: "input name is required but cannot be requested in non-interactive mode",
+		},
+		"input named after a built-in flag": {
+			args:   []string{"--name", "api", "--env", "dev", "--force"},
+			inputs: map[string]string{"name": "api", "env": "dev", "force": "no"},
+			force:  true,
+		},
+	}
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		e := exec.EmptyExec("./Dredgefile", nil, CliCallbacks{NonInteractive: &nonInteractive})
+		w := &workflow.Workflow{
+			Name: "deploy",
+			Inputs: []config.Input{
+				{Name: "name", Required: true},
+				{Name: "env", Type: config.INPUT_SELECT, Values: []string{"dev", "prod"}},
+				{Name: "force", DefaultValue: "no"},
+			},
+			Callbacks: e,
+		}
+		command, err := createWorkflowCommand(w)
+		assert.Nil(t, err)
+		command.SetArgs(test.args)
+		command.SetOut(&bytes.Buffer{})
+		command.SetErr(&bytes.Buffer{})
+		err = command.Execute()
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			for name, value := range test.inputs {
+				assert.Equal(t, value, e.Env[name])
+			}
+			assert.Equal(t, test.force, w.Force)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
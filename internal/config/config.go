@@ -9,33 +9,61 @@ import (
 )
 
 const (
-	DEFAULT_HOME      = "/home"
-	INPUT_TEXT        = "text"
-	INPUT_SELECT      = "select"
-	INSERT_BEGIN      = "begin"
-	INSERT_END        = "end"
-	INSERT_UNIQUE     = "unique"
-	RUNTIME_NATIVE    = "native"
-	RUNTIME_CONTAINER = "container"
-	LOG_FATAL         = "fatal"
-	LOG_ERROR         = "error"
-	LOG_WARN          = "warn"
-	LOG_INFO          = "info"
-	LOG_DEBUG         = "debug"
-	LOG_TRACE         = "trace"
+	DEFAULT_HOME          = "/home"
+	DEFAULT_FOREACH_AS    = "item"
+	DEFAULT_FOREACH_INDEX = "index"
+	INPUT_TEXT            = "text"
+	INPUT_SELECT          = "select"
+	INPUT_BOOL            = "bool"
+	INPUT_NUMBER          = "number"
+	INPUT_MULTISELECT     = "multiselect"
+	INPUT_PASSWORD        = "password"
+	INPUT_FILE            = "file"
+	INSERT_BEGIN          = "begin"
+	INSERT_END            = "end"
+	INSERT_UNIQUE         = "unique"
+	RUNTIME_NATIVE        = "native"
+	RUNTIME_CONTAINER     = "container"
+	LOG_FATAL             = "fatal"
+	LOG_ERROR             = "error"
+	LOG_WARN              = "warn"
+	LOG_INFO              = "info"
+	LOG_DEBUG             = "debug"
+	LOG_TRACE             = "trace"
+	FIELD_STRING          = "string"
+	FIELD_DATE            = "date"
 )
 
 type DredgeFile struct {
 	Variables Variables  `yaml:",omitempty"`
+	Secrets   Secrets    `yaml:",omitempty"`
 	Runtimes  []Runtime  `yaml:",omitempty"`
 	Workflows []Workflow `yaml:",omitempty"`
 	Buckets   []Bucket   `yaml:",omitempty"`
 	Resources Resources  `yaml:",omitempty"`
+	Projects  []string   `yaml:",omitempty"`
+
+	ResourceDefinitions []ResourceDefinition `yaml:"resource_definitions,omitempty"`
 }
 
+var INPUT_TYPES = []string{INPUT_TEXT, INPUT_SELECT, INPUT_BOOL, INPUT_NUMBER, INPUT_MULTISELECT, INPUT_PASSWORD, INPUT_FILE}
+
+// WORKFLOW_FLAGS are the flags of workflow commands and the persistent flags
+// of drg, inputs can't use their names as the inputs are passed as flags too.
+var WORKFLOW_FLAGS = []string{"only", "force", "help", "verbose", "non-interactive", "profile", "all"}
+
+var FIELD_TYPES = []string{FIELD_STRING, FIELD_DATE}
+
 type Variables map[string]string
 type SourcePath string
 
+type Secrets map[string]Secret
+
+type Secret struct {
+	Backend string            `yaml:",omitempty"`
+	Config  map[string]string `yaml:",omitempty"`
+}
+
 type Runtime struct {
 	Name        string
 	Type        string
@@ -62,6 +90,7 @@ type ImportBucket struct {
 type Workflow struct {
 	Name        string
 	Description string          `yaml:",omitempty"`
+	Needs       []string        `yaml:",omitempty"`
 	Inputs      []Input         `yaml:",omitempty"`
 	Steps       []Step          `yaml:",omitempty"`
 	Import      *ImportWorkflow `yaml:",omitempty"`
@@ -74,12 +103,19 @@ type ImportWorkflow struct {
 }
 
 type Input struct {
-	Name         string
-	Description  string   `yaml:",omitempty"`
-	Type         string   `yaml:",omitempty"`
-	Values       []string `yaml:",omitempty"`
-	DefaultValue string   `yaml:"default_value,omitempty"`
-	Skip         string   `yaml:",omitempty"`
+	Name              string
+	Description       string   `yaml:",omitempty"`
+	Type              string   `yaml:",omitempty"`
+	Values            []string `yaml:",omitempty"`
+	DefaultValue      string   `yaml:"default_value,omitempty"`
+	Skip              string   `yaml:",omitempty"`
+	Min               *int     `yaml:",omitempty"`
+	Max               *int     `yaml:",omitempty"`
+	Required          bool     `yaml:",omitempty"`
+	Pattern           string   `yaml:",omitempty"`
+	MinLength         int      `yaml:"min_length,omitempty"`
+	MaxLength         int      `yaml:"max_length,omitempty"`
+	ValidationMessage string   `yaml:"validation_message,omitempty"`
 }
 
 type Step struct {
@@ -93,13 +129,17 @@ type Step struct {
 	Set            *SetStep            `yaml:",omitempty"`
 	Log            *LogStep            `yaml:",omitempty"`
 	Confirm        *ConfirmStep        `yaml:",omitempty"`
+	Parallel       *ParallelStep       `yaml:",omitempty"`
+	Foreach        *ForeachStep        `yaml:",omitempty"`
 }
 
 type ShellStep struct {
 	Cmd     string
-	Runtime string `yaml:",omitempty"`
-	StdOut  string `yaml:"stdout,omitempty"`
-	StdErr  string `yaml:"stderr,omitempty"`
+	Runtime string   `yaml:",omitempty"`
+	StdOut  string   `yaml:"stdout,omitempty"`
+	StdErr  string   `yaml:"stderr,omitempty"`
+	Sources []string `yaml:",omitempty"`
+	Outputs []string `yaml:",omitempty"`
 }
 
 type TemplateStep struct {
@@ -146,15 +186,56 @@ type ConfirmStep struct {
 	Message string
 }
 
+type ParallelStep struct {
+	Steps    []Step
+	Limit    int  `yaml:",omitempty"`
+	FailFast bool `yaml:"fail_fast,omitempty"`
+}
+
+type ForeachStep struct {
+	Items    string
+	As       string `yaml:",omitempty"`
+	Index    string `yaml:",omitempty"`
+	Steps    []Step
+	Parallel int    `yaml:",omitempty"`
+	BreakIf  string `yaml:"break_if,omitempty"`
+}
+
 type Resources map[string]Resource
 
 type Resource []ResourceProvider
 
 type ResourceProvider struct {
 	Provider string
+	Plugin   string            `yaml:",omitempty"`
 	Config   map[string]string `yaml:",omitempty"`
 }
 
+type ResourceDefinition struct {
+	Name     string
+	Fields   []ResourceField   `yaml:",omitempty"`
+	Commands []ResourceCommand `yaml:",omitempty"`
+}
+
+type ResourceField struct {
+	Name        string
+	Description string `yaml:",omitempty"`
+	Type        string `yaml:",omitempty"`
+}
+
+type ResourceCommand struct {
+	Name       string
+	Inputs     []Input `yaml:",omitempty"`
+	OutputType string  `yaml:"output_type"`
+}
+
+func (f ResourceField) GetType() string {
+	if f.Type == "" {
+		return FIELD_STRING
+	}
+	return f.Type
+}
+
 func NewDredgeFile(buf []byte) (*DredgeFile, error) {
 	dredgeFile := &DredgeFile{}
 	err := yaml.Unmarshal(buf, dredgeFile)
@@ -194,6 +275,30 @@ func (r Runtime) GetHome() string {
 	return r.Home
 }
 
+// SplitWorkflowRef splits a reference to a workflow (<workflow> or
+// <bucket>/<workflow>) into the bucket and workflow name.
+func SplitWorkflowRef(ref string) (string, string) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+func (f ForeachStep) GetAs() string {
+	if f.As == "" {
+		return DEFAULT_FOREACH_AS
+	}
+	return f.As
+}
+
+func (f ForeachStep) GetIndex() string {
+	if f.Index == "" {
+		return DEFAULT_FOREACH_INDEX
+	}
+	return f.Index
+}
+
 func (i Input) HasValue(value string) bool {
 	for _, v := range i.Values {
 		if value == v {
@@ -202,3 +307,29 @@ func (i Input) HasValue(value string) bool {
 	}
 	return false
 }
+
+// SplitList splits the value of a multiselect input into its items.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// IsTrue returns true for the values that are true for bool inputs and
+// conditions: 1, t, true and yes.
+func IsTrue(s string) bool {
+	l := strings.ToLower(s)
+	return l == "1" || l == "t" || l == "true" || l == "yes"
+}
+
+// IsFalse returns true for the values that are false for bool inputs: 0, f,
+// false and no.
+func IsFalse(s string) bool {
+	l := strings.ToLower(s)
+	return l == "0" || l == "f" || l == "false" || l == "no"
+}
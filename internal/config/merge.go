@@ -0,0 +1,169 @@
+package config
+
+// Origins maps the values of a merged Dredgefile to the file they come from.
+// The keys are <section>.<name>, e.g. variables.PORT or workflows.build, and
+// projects for the list of projects.
+type Origins map[string]string
+
+const (
+	SECTION_VARIABLES            = "variables"
+	SECTION_SECRETS              = "secrets"
+	SECTION_RUNTIMES             = "runtimes"
+	SECTION_WORKFLOWS            = "workflows"
+	SECTION_BUCKETS              = "buckets"
+	SECTION_RESOURCES            = "resources"
+	SECTION_RESOURCE_DEFINITIONS = "resource_definitions"
+	SECTION_PROJECTS             = "projects"
+)
+
+// Merge returns a Dredgefile with the values of override on top of the
+// values of dredgeFile, neither of them is modified. Variables and secrets
+// are merged by name. Runtimes, workflows, buckets and resource definitions
+// with the same name are replaced as a whole, so are the providers of a
+// resource. Values that are not overridden keep their order, new values are
+// added at the end. Projects are replaced when override has projects. The
+// origin of the values in override is stored in origins.
+func (dredgeFile *DredgeFile) Merge(override *DredgeFile, origin string, origins Origins) *DredgeFile {
+	merged := &DredgeFile{}
+
+	merged.Variables = mergeVariables(dredgeFile.Variables, override.Variables)
+	for name := range override.Variables {
+		origins[SECTION_VARIABLES+"."+name] = origin
+	}
+
+	if len(dredgeFile.Secrets) > 0 || len(override.Secrets) > 0 {
+		merged.Secrets = make(Secrets)
+		for name, secret := range dredgeFile.Secrets {
+			merged.Secrets[name] = secret
+		}
+		for name, secret := range override.Secrets {
+			merged.Secrets[name] = secret
+			origins[SECTION_SECRETS+"."+name] = origin
+		}
+	}
+
+	merged.Runtimes = append([]Runtime(nil), dredgeFile.Runtimes...)
+	for _, r := range override.Runtimes {
+		origins[SECTION_RUNTIMES+"."+r.Name] = origin
+		merged.Runtimes = replaceRuntime(merged.Runtimes, r)
+	}
+
+	merged.Workflows = append([]Workflow(nil), dredgeFile.Workflows...)
+	for _, w := range override.Workflows {
+		origins[SECTION_WORKFLOWS+"."+w.Name] = origin
+		merged.Workflows = replaceWorkflow(merged.Workflows, w)
+	}
+
+	merged.Buckets = append([]Bucket(nil), dredgeFile.Buckets...)
+	for _, b := range override.Buckets {
+		origins[SECTION_BUCKETS+"."+b.Name] = origin
+		merged.Buckets = replaceBucket(merged.Buckets, b)
+	}
+
+	if len(dredgeFile.Resources) > 0 || len(override.Resources) > 0 {
+		merged.Resources = make(Resources)
+		for name, r := range dredgeFile.Resources {
+			merged.Resources[name] = r
+		}
+		for name, r := range override.Resources {
+			merged.Resources[name] = r
+			origins[SECTION_RESOURCES+"."+name] = origin
+		}
+	}
+
+	merged.ResourceDefinitions = append([]ResourceDefinition(nil), dredgeFile.ResourceDefinitions...)
+	for _, rd := range override.ResourceDefinitions {
+		origins[SECTION_RESOURCE_DEFINITIONS+"."+rd.Name] = origin
+		merged.ResourceDefinitions = replaceResourceDefinition(merged.ResourceDefinitions, rd)
+	}
+
+	merged.Projects = dredgeFile.Projects
+	if len(override.Projects) > 0 {
+		merged.Projects = override.Projects
+		origins[SECTION_PROJECTS] = origin
+	}
+
+	return merged
+}
+
+// AddOrigins stores origin as the origin of all values in dredgeFile.
+func (dredgeFile *DredgeFile) AddOrigins(origin string, origins Origins) {
+	for name := range dredgeFile.Variables {
+		origins[SECTION_VARIABLES+"."+name] = origin
+	}
+	for name := range dredgeFile.Secrets {
+		origins[SECTION_SECRETS+"."+name] = origin
+	}
+	for _, r := range dredgeFile.Runtimes {
+		origins[SECTION_RUNTIMES+"."+r.Name] = origin
+	}
+	for _, w := range dredgeFile.Workflows {
+		origins[SECTION_WORKFLOWS+"."+w.Name] = origin
+	}
+	for _, b := range dredgeFile.Buckets {
+		origins[SECTION_BUCKETS+"."+b.Name] = origin
+	}
+	for name := range dredgeFile.Resources {
+		origins[SECTION_RESOURCES+"."+name] = origin
+	}
+	for _, rd := range dredgeFile.ResourceDefinitions {
+		origins[SECTION_RESOURCE_DEFINITIONS+"."+rd.Name] = origin
+	}
+	if len(dredgeFile.Projects) > 0 {
+		origins[SECTION_PROJECTS] = origin
+	}
+}
+
+func mergeVariables(base, override Variables) Variables {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(Variables)
+	for name, value := range base {
+		merged[name] = value
+	}
+	for name, value := range override {
+		merged[name] = value
+	}
+	return merged
+}
+
+func replaceRuntime(runtimes []Runtime, r Runtime) []Runtime {
+	for i := range runtimes {
+		if runtimes[i].Name == r.Name {
+			runtimes[i] = r
+			return runtimes
+		}
+	}
+	return append(runtimes, r)
+}
+
+func replaceWorkflow(workflows []Workflow, w Workflow) []Workflow {
+	for i := range workflows {
+		if workflows[i].Name == w.Name {
+			workflows[i] = w
+			return workflows
+		}
+	}
+	return append(workflows, w)
+}
+
+func replaceBucket(buckets []Bucket, b Bucket) []Bucket {
+	for i := range buckets {
+		if buckets[i].Name == b.Name {
+			buckets[i] = b
+			return buckets
+		}
+	}
+	return append(buckets, b)
+}
+
+func replaceResourceDefinition(rds []ResourceDefinition, rd ResourceDefinition) []ResourceDefinition {
+	for i := range rds {
+		if rds[i].Name == rd.Name {
+			rds[i] = rd
+			return rds
+		}
+	}
+	return append(rds, rd)
+}
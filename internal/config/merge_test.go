@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := &DredgeFile{
+		Variables: Variables{"PORT": "8080", "IMAGE": "app:latest"},
+		Runtimes: []Runtime{
+			{Name: "go", Type: RUNTIME_CONTAINER, Image: "golang:1.17"},
+			{Name: "node", Type: RUNTIME_CONTAINER, Image: "node:16"},
+		},
+		Workflows: []Workflow{{Name: "build"}, {Name: "test"}},
+		Buckets:   []Bucket{{Name: "release", Description: "Release"}},
+		Resources: Resources{
+			"issue": {{Provider: "github-issues"}},
+			"doc":   {{Provider: "local-doc"}},
+		},
+	}
+	override := &DredgeFile{
+		Variables: Variables{"PORT": "9090"},
+		Secrets:   Secrets{"TOKEN": {Backend: "env"}},
+		Runtimes:  []Runtime{{Name: "go", Type: RUNTIME_CONTAINER, Image: "golang:1.18"}},
+		Workflows: []Workflow{{Name: "test", Description: "Quick tests"}, {Name: "dev"}},
+		Resources: Resources{"issue": {{Provider: "jira"}}},
+	}
+
+	origins := Origins{}
+	base.AddOrigins("./Dredgefile", origins)
+	merged := base.Merge(override, "./Dredgefile.local", origins)
+
+	assert.Equal(t, &DredgeFile{
+		Variables: Variables{"PORT": "9090", "IMAGE": "app:latest"},
+		Secrets:   Secrets{"TOKEN": {Backend: "env"}},
+		Runtimes: []Runtime{
+			{Name: "go", Type: RUNTIME_CONTAINER, Image: "golang:1.18"},
+			{Name: "node", Type: RUNTIME_CONTAINER, Image: "node:16"},
+		},
+		Workflows: []Workflow{{Name: "build"}, {Name: "test", Description: "Quick tests"}, {Name: "dev"}},
+		Buckets:   []Bucket{{Name: "release", Description: "Release"}},
+		Resources: Resources{
+			"issue": {{Provider: "jira"}},
+			"doc":   {{Provider: "local-doc"}},
+		},
+	}, merged)
+	assert.Equal(t, Origins{
+		"variables.PORT":  "./Dredgefile.local",
+		"variables.IMAGE": "./Dredgefile",
+		"secrets.TOKEN":   "./Dredgefile.local",
+		"runtimes.go":     "./Dredgefile.local",
+		"runtimes.node":   "./Dredgefile",
+		"workflows.build": "./Dredgefile",
+		"workflows.test":  "./Dredgefile.local",
+		"workflows.dev":   "./Dredgefile.local",
+		"buckets.release": "./Dredgefile",
+		"resources.issue": "./Dredgefile.local",
+		"resources.doc":   "./Dredgefile",
+	}, origins)
+
+	assert.Equal(t, "8080", base.Variables["PORT"])
+	assert.Equal(t, "golang:1.17", base.Runtimes[0].Image)
+	assert.Equal(t, 2, len(base.Workflows))
+}
@@ -2,9 +2,17 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 func (dredgeFile *DredgeFile) Validate() error {
+	if err := dredgeFile.validateSecrets(); err != nil {
+		return err
+	}
 	for _, r := range dredgeFile.Runtimes {
 		if err := r.Validate(); err != nil {
 			return err
@@ -20,10 +28,141 @@ func (dredgeFile *DredgeFile) Validate() error {
 			return err
 		}
 	}
+	if err := dredgeFile.validateNeeds(); err != nil {
+		return err
+	}
+	for _, p := range dredgeFile.Projects {
+		if _, err := filepath.Match(p, ""); err != nil || p == "" || filepath.IsAbs(p) {
+			return fmt.Errorf("invalid project pattern %s (expected a relative path or glob)", p)
+		}
+	}
+	names := make(map[string]bool)
+	for _, rd := range dredgeFile.ResourceDefinitions {
+		if err := rd.Validate(); err != nil {
+			return err
+		}
+		if names[rd.Name] {
+			return fmt.Errorf("resource definition %s is defined more than once", rd.Name)
+		}
+		names[rd.Name] = true
+	}
 	// TODO Validate resources here.
 	return nil
 }
 
+var SECRET_NAME_RE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (dredgeFile *DredgeFile) validateSecrets() error {
+	for name := range dredgeFile.Secrets {
+		if !SECRET_NAME_RE.MatchString(name) {
+			return fmt.Errorf("invalid secret name %s (expected letters, digits and underscores)", name)
+		}
+		if _, ok := dredgeFile.Variables[name]; ok {
+			return fmt.Errorf("%s is defined as variable and secret", name)
+		}
+	}
+	return nil
+}
+
+func (rd ResourceDefinition) Validate() error {
+	if rd.Name == "" {
+		return fmt.Errorf("name field is required for resource definition")
+	}
+	fields := make(map[string]bool)
+	for _, f := range rd.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("resource definition %s: name field is required for fields", rd.Name)
+		}
+		if fields[f.Name] {
+			return fmt.Errorf("resource definition %s: field %s is defined more than once", rd.Name, f.Name)
+		}
+		fields[f.Name] = true
+		if !contains(FIELD_TYPES, f.GetType()) {
+			return fmt.Errorf("resource definition %s: field %s: unknown type %s (valid options are: %s)", rd.Name, f.Name, f.Type, strings.Join(FIELD_TYPES, ", "))
+		}
+	}
+	commands := make(map[string]bool)
+	for _, c := range rd.Commands {
+		if c.Name == "" {
+			return fmt.Errorf("resource definition %s: name field is required for commands", rd.Name)
+		}
+		if commands[c.Name] {
+			return fmt.Errorf("resource definition %s: command %s is defined more than once", rd.Name, c.Name)
+		}
+		commands[c.Name] = true
+		if c.OutputType == "" {
+			return fmt.Errorf("resource definition %s: command %s: output_type field is required", rd.Name, c.Name)
+		}
+		for _, i := range c.Inputs {
+			if err := i.Validate(); err != nil {
+				return fmt.Errorf("resource definition %s: command %s: %v", rd.Name, c.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNeeds checks that the workflows in needs exist and that the
+// dependencies between workflows don't contain cycles. Workflows in imported
+// buckets are only checked when the import is resolved.
+func (dredgeFile *DredgeFile) validateNeeds() error {
+	needs := make(map[string][]string)
+	importedBuckets := make(map[string]bool)
+	for _, w := range dredgeFile.Workflows {
+		needs[w.Name] = w.Needs
+	}
+	for _, b := range dredgeFile.Buckets {
+		if b.Import != nil {
+			importedBuckets[b.Name] = true
+		}
+		for _, w := range b.Workflows {
+			needs[b.Name+"/"+w.Name] = w.Needs
+		}
+	}
+
+	var refs []string
+	for ref := range needs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	for _, ref := range refs {
+		for _, need := range needs[ref] {
+			bucket, _ := SplitWorkflowRef(need)
+			if _, ok := needs[need]; !ok && !importedBuckets[bucket] {
+				return fmt.Errorf("workflow %s: could not find workflow %s in needs", ref, need)
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	for _, ref := range refs {
+		if err := findNeedsCycle(ref, needs, visited, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findNeedsCycle(ref string, needs map[string][]string, visited map[string]bool, path []string) error {
+	for i, r := range path {
+		if r == ref {
+			return fmt.Errorf("dependency cycle in needs: %s", strings.Join(append(path[i:], ref), " -> "))
+		}
+	}
+	if visited[ref] {
+		return nil
+	}
+	path = append(path, ref)
+	for _, need := range needs[ref] {
+		if err := findNeedsCycle(need, needs, visited, path); err != nil {
+			return err
+		}
+	}
+	visited[ref] = true
+	return nil
+}
+
 func (r Runtime) Validate() error {
 	if r.Name == "" {
 		return fmt.Errorf("name field is required for runtime")
@@ -89,6 +228,9 @@ func (w Workflow) Validate() error {
 		if err := i.Validate(); err != nil {
 			return fmt.Errorf("workflow %s: %v", w.Name, err)
 		}
+		if contains(WORKFLOW_FLAGS, i.Name) {
+			return fmt.Errorf("workflow %s: input %s conflicts with the --%s flag of workflows (reserved names are: %s)", w.Name, i.Name, i.Name, strings.Join(WORKFLOW_FLAGS, ", "))
+		}
 	}
 	if len(w.Steps) == 0 {
 		return fmt.Errorf("workflow %s: no steps or import defined", w.Name)
@@ -112,21 +254,91 @@ func (i Input) Validate() error {
 	if i.Name == "" {
 		return fmt.Errorf("name field is required on inputs")
 	}
-	if i.Type != "" && i.Type != INPUT_TEXT && i.Type != INPUT_SELECT {
-		return fmt.Errorf("input %s: unknown input type: %s (valid options are: %s, %s)", i.Name, i.Type, INPUT_TEXT, INPUT_SELECT)
+	if i.Type != "" && !contains(INPUT_TYPES, i.Type) {
+		return fmt.Errorf("input %s: unknown input type: %s (valid options are: %s)", i.Name, i.Type, strings.Join(INPUT_TYPES, ", "))
 	}
-	if len(i.Values) > 0 && (i.Type == "" || i.Type == INPUT_TEXT) {
-		return fmt.Errorf("input %s: values for input can only be provided for the %s type", i.Name, INPUT_SELECT)
+	hasValues := i.Type == INPUT_SELECT || i.Type == INPUT_MULTISELECT
+	if len(i.Values) > 0 && !hasValues {
+		return fmt.Errorf("input %s: values for input can only be provided for the %s and %s types", i.Name, INPUT_SELECT, INPUT_MULTISELECT)
 	}
-	if len(i.Values) == 0 && i.Type == INPUT_SELECT {
-		return fmt.Errorf("input %s: no values are provided, values are required for the %s type", i.Name, INPUT_SELECT)
+	if len(i.Values) == 0 && hasValues {
+		return fmt.Errorf("input %s: no values are provided, values are required for the %s type", i.Name, i.Type)
 	}
-	if i.DefaultValue != "" && i.Type == INPUT_SELECT {
-		return fmt.Errorf("input %s: default value can only be provided for the %s type", i.Name, INPUT_TEXT)
+	if (i.Min != nil || i.Max != nil) && i.Type != INPUT_NUMBER {
+		return fmt.Errorf("input %s: min and max can only be provided for the %s type", i.Name, INPUT_NUMBER)
+	}
+	if i.Min != nil && i.Max != nil && *i.Min > *i.Max {
+		return fmt.Errorf("input %s: min (%d) cannot be larger than max (%d)", i.Name, *i.Min, *i.Max)
+	}
+	if i.Pattern != "" {
+		if _, err := regexp.Compile(i.Pattern); err != nil {
+			return fmt.Errorf("input %s: invalid pattern: %v", i.Name, err)
+		}
+	}
+	if i.MinLength < 0 || i.MaxLength < 0 {
+		return fmt.Errorf("input %s: min_length and max_length cannot be negative", i.Name)
+	}
+	if i.MaxLength > 0 && i.MinLength > i.MaxLength {
+		return fmt.Errorf("input %s: min_length (%d) cannot be larger than max_length (%d)", i.Name, i.MinLength, i.MaxLength)
+	}
+	if (i.Pattern != "" || i.MinLength > 0 || i.MaxLength > 0) && i.Type != "" && i.Type != INPUT_TEXT && i.Type != INPUT_PASSWORD {
+		return fmt.Errorf("input %s: pattern, min_length and max_length can only be provided for the %s and %s types", i.Name, INPUT_TEXT, INPUT_PASSWORD)
+	}
+	if i.DefaultValue != "" {
+		if i.Type == INPUT_PASSWORD {
+			return fmt.Errorf("input %s: default value cannot be provided for the %s type", i.Name, INPUT_PASSWORD)
+		}
+		if err := i.validateDefaultValue(); err != nil {
+			return fmt.Errorf("input %s: invalid default value %s: %v", i.Name, i.DefaultValue, err)
+		}
+	}
+	return nil
+}
+
+func (i Input) validateDefaultValue() error {
+	switch i.Type {
+	case "", INPUT_TEXT:
+		if i.Pattern != "" && !regexp.MustCompile(i.Pattern).MatchString(i.DefaultValue) {
+			return fmt.Errorf("does not match pattern %s", i.Pattern)
+		}
+		if len(i.DefaultValue) < i.MinLength || (i.MaxLength > 0 && len(i.DefaultValue) > i.MaxLength) {
+			return fmt.Errorf("length out of range")
+		}
+	case INPUT_SELECT:
+		if !i.HasValue(i.DefaultValue) {
+			return fmt.Errorf("not one of the values")
+		}
+	case INPUT_MULTISELECT:
+		for _, v := range SplitList(i.DefaultValue) {
+			if !i.HasValue(v) {
+				return fmt.Errorf("%s is not one of the values", v)
+			}
+		}
+	case INPUT_BOOL:
+		if !IsTrue(i.DefaultValue) && !IsFalse(i.DefaultValue) {
+			return fmt.Errorf("expected true or false")
+		}
+	case INPUT_NUMBER:
+		n, err := strconv.Atoi(i.DefaultValue)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		if (i.Min != nil && n < *i.Min) || (i.Max != nil && n > *i.Max) {
+			return fmt.Errorf("out of range")
+		}
 	}
 	return nil
 }
 
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (s Step) Validate() error {
 	numFields := 0
 
@@ -193,6 +405,20 @@ func (s Step) Validate() error {
 			return err
 		}
 	}
+	if s.Parallel != nil {
+		numFields += 1
+		err := s.Parallel.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Foreach != nil {
+		numFields += 1
+		err := s.Foreach.Validate()
+		if err != nil {
+			return err
+		}
+	}
 	if numFields == 0 {
 		return fmt.Errorf("step %s does not contain an action", s.Name)
 	} else if numFields == 1 {
@@ -206,6 +432,12 @@ func (s ShellStep) Validate() error {
 	if s.Cmd == "" {
 		return fmt.Errorf("cmd field is required for shell")
 	}
+	if len(s.Outputs) > 0 && len(s.Sources) == 0 {
+		return fmt.Errorf("outputs can only be used together with sources for shell")
+	}
+	if len(s.Sources) > 0 && (s.StdOut != "" || s.StdErr != "") {
+		return fmt.Errorf("stdout and stderr cannot be used together with sources for shell")
+	}
 	return nil
 }
 
@@ -288,3 +520,39 @@ func (c ConfirmStep) Validate() error {
 	}
 	return nil
 }
+
+func (p ParallelStep) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("1 or more steps are required for parallel")
+	}
+	if p.Limit < 0 {
+		return fmt.Errorf("limit for parallel cannot be negative")
+	}
+	for _, s := range p.Steps {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("parallel: %v", err)
+		}
+	}
+	return nil
+}
+
+func (f ForeachStep) Validate() error {
+	if f.Items == "" {
+		return fmt.Errorf("items field is required for foreach")
+	}
+	if len(f.Steps) == 0 {
+		return fmt.Errorf("1 or more steps are required for foreach")
+	}
+	if f.GetAs() == f.GetIndex() {
+		return fmt.Errorf("as and index cannot use the same name (%s) in foreach", f.GetAs())
+	}
+	if f.Parallel < 0 {
+		return fmt.Errorf("parallel for foreach cannot be negative")
+	}
+	for _, s := range f.Steps {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("foreach: %v", err)
+		}
+	}
+	return nil
+}
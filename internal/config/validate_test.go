@@ -123,6 +123,30 @@ func TestValidate(t *testing.T) {
 			},
 			errorMsg: "",
 		},
+		"workflow with input named after a flag": {
+			dredgeFile: &DredgeFile{
+				Workflows: []Workflow{
+					{
+						Name:   "w1",
+						Inputs: []Input{{Name: "force"}},
+						Steps:  []Step{{Shell: &ShellStep{Cmd: "test"}}},
+					},
+				},
+			},
+			errorMsg: "workflow w1: input force conflicts with the --force flag of workflows (reserved names are: only, force, help, verbose, non-interactive, profile, all)",
+		},
+		"workflow with input named after a persistent flag": {
+			dredgeFile: &DredgeFile{
+				Workflows: []Workflow{
+					{
+						Name:   "w1",
+						Inputs: []Input{{Name: "profile"}},
+						Steps:  []Step{{Shell: &ShellStep{Cmd: "test"}}},
+					},
+				},
+			},
+			errorMsg: "workflow w1: input profile conflicts with the --profile flag of workflows (reserved names are: only, force, help, verbose, non-interactive, profile, all)",
+		},
 		"workflow no steps no import": {
 			dredgeFile: &DredgeFile{
 				Workflows: []Workflow{
@@ -153,6 +177,74 @@ func TestValidate(t *testing.T) {
 			},
 			errorMsg: "workflow w1: contains both steps and an import",
 		},
+		"workflow with needs": {
+			dredgeFile: &DredgeFile{
+				Workflows: []Workflow{
+					{
+						Name:  "deploy",
+						Needs: []string{"build", "b1/test", "imported/lint"},
+						Steps: []Step{{Shell: &ShellStep{Cmd: "deploy"}}},
+					},
+					{
+						Name:  "build",
+						Steps: []Step{{Shell: &ShellStep{Cmd: "build"}}},
+					},
+				},
+				Buckets: []Bucket{
+					{
+						Name: "b1",
+						Workflows: []Workflow{
+							{
+								Name:  "test",
+								Needs: []string{"build"},
+								Steps: []Step{{Shell: &ShellStep{Cmd: "test"}}},
+							},
+						},
+					},
+					{
+						Name:   "imported",
+						Import: &ImportBucket{Bucket: "b2"},
+					},
+				},
+			},
+			errorMsg: "",
+		},
+		"workflow with unknown need": {
+			dredgeFile: &DredgeFile{
+				Workflows: []Workflow{
+					{
+						Name:  "deploy",
+						Needs: []string{"b1/build"},
+						Steps: []Step{{Shell: &ShellStep{Cmd: "deploy"}}},
+					},
+				},
+			},
+			errorMsg: "workflow deploy: could not find workflow b1/build in needs",
+		},
+		"workflow with cycle in needs": {
+			dredgeFile: &DredgeFile{
+				Workflows: []Workflow{
+					{
+						Name:  "build",
+						Needs: []string{"b1/test"},
+						Steps: []Step{{Shell: &ShellStep{Cmd: "build"}}},
+					},
+				},
+				Buckets: []Bucket{
+					{
+						Name: "b1",
+						Workflows: []Workflow{
+							{
+								Name:  "test",
+								Needs: []string{"build"},
+								Steps: []Step{{Shell: &ShellStep{Cmd: "test"}}},
+							},
+						},
+					},
+				},
+			},
+			errorMsg: "dependency cycle in needs: b1/test -> build -> b1/test",
+		},
 		"runtime validation": {
 			dredgeFile: &DredgeFile{
 				Runtimes: []Runtime{
@@ -161,6 +253,124 @@ func TestValidate(t *testing.T) {
 			},
 			errorMsg: "name field is required for runtime",
 		},
+		"valid resource definition": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{
+						Name: "incident",
+						Fields: []ResourceField{
+							{Name: "name"},
+							{Name: "opened", Type: "date"},
+						},
+						Commands: []ResourceCommand{
+							{Name: "get", OutputType: "[]incident"},
+							{Name: "create", Inputs: []Input{{Name: "title"}}, OutputType: "incident"},
+						},
+					},
+				},
+			},
+			errorMsg: "",
+		},
+		"resource definition without name": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{},
+				},
+			},
+			errorMsg: "name field is required for resource definition",
+		},
+		"duplicate resource definition": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{Name: "incident"},
+					{Name: "incident"},
+				},
+			},
+			errorMsg: "resource definition incident is defined more than once",
+		},
+		"resource definition with unknown field type": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{
+						Name:   "incident",
+						Fields: []ResourceField{{Name: "severity", Type: "int"}},
+					},
+				},
+			},
+			errorMsg: "resource definition incident: field severity: unknown type int (valid options are: string, date)",
+		},
+		"resource definition with duplicate field": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{
+						Name:   "incident",
+						Fields: []ResourceField{{Name: "name"}, {Name: "name"}},
+					},
+				},
+			},
+			errorMsg: "resource definition incident: field name is defined more than once",
+		},
+		"resource definition command without output type": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{
+						Name:     "incident",
+						Commands: []ResourceCommand{{Name: "get"}},
+					},
+				},
+			},
+			errorMsg: "resource definition incident: command get: output_type field is required",
+		},
+		"resource definition command with invalid input": {
+			dredgeFile: &DredgeFile{
+				ResourceDefinitions: []ResourceDefinition{
+					{
+						Name: "incident",
+						Commands: []ResourceCommand{
+							{Name: "create", Inputs: []Input{{Name: "severity", Type: "level"}}, OutputType: "incident"},
+						},
+					},
+				},
+			},
+			errorMsg: "resource definition incident: command create: input severity: unknown input type: level (valid options are: text, select, bool, number, multiselect, password, file)",
+		},
+		"valid secret": {
+			dredgeFile: &DredgeFile{
+				Secrets: Secrets{"DB_PASSWORD": {Backend: "env"}},
+			},
+			errorMsg: "",
+		},
+		"secret with invalid name": {
+			dredgeFile: &DredgeFile{
+				Secrets: Secrets{"db-password": {}},
+			},
+			errorMsg: "invalid secret name db-password (expected letters, digits and underscores)",
+		},
+		"secret defined as variable": {
+			dredgeFile: &DredgeFile{
+				Variables: Variables{"TOKEN": "abc"},
+				Secrets:   Secrets{"TOKEN": {}},
+			},
+			errorMsg: "TOKEN is defined as variable and secret",
+		},
+		"valid projects": {
+			dredgeFile: &DredgeFile{
+				Projects: []string{"services/*", "tools/cli"},
+			},
+			errorMsg: "",
+		},
+		"invalid project pattern": {
+			dredgeFile: &DredgeFile{
+				Projects: []string{"services/[a"},
+			},
+			errorMsg: "invalid project pattern services/[a (expected a relative path or glob)",
+		},
+		"absolute project": {
+			dredgeFile: &DredgeFile{
+				Projects: []string{"/srv/app"},
+			},
+			errorMsg: "invalid project pattern /srv/app (expected a relative path or glob)",
+		},
 	}
 
 	for testName, test := range tests {
@@ -250,7 +460,7 @@ func TestInputValidate(t *testing.T) {
 				Name: "test",
 				Type: "invalid",
 			},
-			errorMsg: "input test: unknown input type: invalid (valid options are: text, select)",
+			errorMsg: "input test: unknown input type: invalid (valid options are: text, select, bool, number, multiselect, password, file)",
 		},
 		"simple input": {
 			input: Input{
@@ -282,7 +492,7 @@ func TestInputValidate(t *testing.T) {
 					"hello", "world",
 				},
 			},
-			errorMsg: "input test: values for input can only be provided for the select type",
+			errorMsg: "input test: values for input can only be provided for the select and multiselect types",
 		},
 		"text input with values": {
 			input: Input{
@@ -292,7 +502,7 @@ func TestInputValidate(t *testing.T) {
 					"hello", "world",
 				},
 			},
-			errorMsg: "input test: values for input can only be provided for the select type",
+			errorMsg: "input test: values for input can only be provided for the select and multiselect types",
 		},
 		"select input": {
 			input: Input{
@@ -324,7 +534,156 @@ func TestInputValidate(t *testing.T) {
 				},
 				DefaultValue: "value1",
 			},
-			errorMsg: "input select-input: default value can only be provided for the text type",
+			errorMsg: "",
+		},
+		"select input with unknown default value": {
+			input: Input{
+				Name:         "select-input",
+				Type:         "select",
+				Values:       []string{"value1", "value2"},
+				DefaultValue: "value3",
+			},
+			errorMsg: "input select-input: invalid default value value3: not one of the values",
+		},
+		"bool input": {
+			input: Input{
+				Name:         "confirm",
+				Type:         "bool",
+				DefaultValue: "yes",
+			},
+			errorMsg: "",
+		},
+		"bool input with invalid default value": {
+			input: Input{
+				Name:         "confirm",
+				Type:         "bool",
+				DefaultValue: "maybe",
+			},
+			errorMsg: "input confirm: invalid default value maybe: expected true or false",
+		},
+		"number input": {
+			input: Input{
+				Name:         "instances",
+				Type:         "number",
+				Min:          intPtr(1),
+				Max:          intPtr(5),
+				DefaultValue: "2",
+			},
+			errorMsg: "",
+		},
+		"number input with default value out of range": {
+			input: Input{
+				Name:         "instances",
+				Type:         "number",
+				Min:          intPtr(1),
+				DefaultValue: "0",
+			},
+			errorMsg: "input instances: invalid default value 0: out of range",
+		},
+		"number input with min larger than max": {
+			input: Input{
+				Name: "instances",
+				Type: "number",
+				Min:  intPtr(5),
+				Max:  intPtr(1),
+			},
+			errorMsg: "input instances: min (5) cannot be larger than max (1)",
+		},
+		"text input with min": {
+			input: Input{
+				Name: "instances",
+				Min:  intPtr(1),
+			},
+			errorMsg: "input instances: min and max can only be provided for the number type",
+		},
+		"multiselect input": {
+			input: Input{
+				Name:         "services",
+				Type:         "multiselect",
+				Values:       []string{"api", "web", "worker"},
+				DefaultValue: "api, web",
+			},
+			errorMsg: "",
+		},
+		"multiselect input without values": {
+			input: Input{
+				Name: "services",
+				Type: "multiselect",
+			},
+			errorMsg: "input services: no values are provided, values are required for the multiselect type",
+		},
+		"multiselect input with unknown default value": {
+			input: Input{
+				Name:         "services",
+				Type:         "multiselect",
+				Values:       []string{"api", "web"},
+				DefaultValue: "api,db",
+			},
+			errorMsg: "input services: invalid default value api,db: db is not one of the values",
+		},
+		"password input with default value": {
+			input: Input{
+				Name:         "token",
+				Type:         "password",
+				DefaultValue: "secret",
+			},
+			errorMsg: "input token: default value cannot be provided for the password type",
+		},
+		"text input with pattern": {
+			input: Input{
+				Name:         "version",
+				Pattern:      "^v[0-9]+\\.[0-9]+\\.[0-9]+$",
+				MinLength:    6,
+				MaxLength:    20,
+				DefaultValue: "v1.0.0",
+			},
+			errorMsg: "",
+		},
+		"text input with invalid pattern": {
+			input: Input{
+				Name:    "version",
+				Pattern: "v[0-9",
+			},
+			errorMsg: "input version: invalid pattern: error parsing regexp: missing closing ]: `[0-9`",
+		},
+		"text input with default not matching pattern": {
+			input: Input{
+				Name:         "version",
+				Pattern:      "^v[0-9]+$",
+				DefaultValue: "1",
+			},
+			errorMsg: "input version: invalid default value 1: does not match pattern ^v[0-9]+$",
+		},
+		"text input with negative min_length": {
+			input: Input{
+				Name:      "version",
+				MinLength: -1,
+			},
+			errorMsg: "input version: min_length and max_length cannot be negative",
+		},
+		"text input with min_length larger than max_length": {
+			input: Input{
+				Name:      "version",
+				MinLength: 5,
+				MaxLength: 2,
+			},
+			errorMsg: "input version: min_length (5) cannot be larger than max_length (2)",
+		},
+		"number input with pattern": {
+			input: Input{
+				Name:    "instances",
+				Type:    "number",
+				Pattern: "^[0-9]$",
+			},
+			errorMsg: "input instances: pattern, min_length and max_length can only be provided for the text and password types",
+		},
+		"file input": {
+			input: Input{
+				Name:         "manifest",
+				Type:         "file",
+				DefaultValue: "./deploy.yml",
+			},
+			errorMsg: "",
 		},
 	}
 	for testName, test := range tests {
@@ -338,6 +697,10 @@ func TestInputValidate(t *testing.T) {
 	}
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
 func TestStepValidate(t *testing.T) {
 	tests := map[string]struct {
 		step     Step
@@ -367,6 +730,18 @@ func TestStepValidate(t *testing.T) {
 			step:     Step{Shell: &ShellStep{}},
 			errorMsg: "cmd field is required for shell",
 		},
+		"shell with sources and outputs": {
+			step:     Step{Shell: &ShellStep{Cmd: "go build -o drg", Sources: []string{"**/*.go", "go.mod"}, Outputs: []string{"drg"}}},
+			errorMsg: "",
+		},
+		"shell with outputs without sources": {
+			step:     Step{Shell: &ShellStep{Cmd: "go build -o drg", Outputs: []string{"drg"}}},
+			errorMsg: "outputs can only be used together with sources for shell",
+		},
+		"shell with sources and stdout": {
+			step:     Step{Shell: &ShellStep{Cmd: "go build -o drg", Sources: []string{"**/*.go"}, StdOut: "OUTPUT"}},
+			errorMsg: "stdout and stderr cannot be used together with sources for shell",
+		},
 		"template": {
 			step: Step{Template: &TemplateStep{
 				Source: "file",
@@ -526,6 +901,101 @@ func TestStepValidate(t *testing.T) {
 			},
 			errorMsg: "message field is required for confirm",
 		},
+		"parallel": {
+			step: Step{
+				Parallel: &ParallelStep{
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "make lint"}},
+						{Shell: &ShellStep{Cmd: "make test"}},
+					},
+					Limit:    2,
+					FailFast: true,
+				},
+			},
+			errorMsg: "",
+		},
+		"parallel without steps": {
+			step: Step{
+				Parallel: &ParallelStep{},
+			},
+			errorMsg: "1 or more steps are required for parallel",
+		},
+		"parallel with negative limit": {
+			step: Step{
+				Parallel: &ParallelStep{
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "make lint"}},
+					},
+					Limit: -1,
+				},
+			},
+			errorMsg: "limit for parallel cannot be negative",
+		},
+		"parallel with invalid step": {
+			step: Step{
+				Parallel: &ParallelStep{
+					Steps: []Step{
+						{Name: "lint"},
+					},
+				},
+			},
+			errorMsg: "parallel: step lint does not contain an action",
+		},
+		"foreach": {
+			step: Step{
+				Foreach: &ForeachStep{
+					Items: "issues",
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "echo {{ .item.name }}"}},
+					},
+					Parallel: 2,
+					BreakIf:  "{{ .done }}",
+				},
+			},
+			errorMsg: "",
+		},
+		"foreach without items": {
+			step: Step{
+				Foreach: &ForeachStep{
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "echo {{ .item }}"}},
+					},
+				},
+			},
+			errorMsg: "items field is required for foreach",
+		},
+		"foreach without steps": {
+			step: Step{
+				Foreach: &ForeachStep{
+					Items: "issues",
+				},
+			},
+			errorMsg: "1 or more steps are required for foreach",
+		},
+		"foreach with same as and index": {
+			step: Step{
+				Foreach: &ForeachStep{
+					Items: "issues",
+					As:    "index",
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "echo {{ .index }}"}},
+					},
+				},
+			},
+			errorMsg: "as and index cannot use the same name (index) in foreach",
+		},
+		"foreach with negative parallel": {
+			step: Step{
+				Foreach: &ForeachStep{
+					Items: "issues",
+					Steps: []Step{
+						{Shell: &ShellStep{Cmd: "echo {{ .item }}"}},
+					},
+					Parallel: -1,
+				},
+			},
+			errorMsg: "parallel for foreach cannot be negative",
+		},
 	}
 
 	for testName, test := range tests {
@@ -3,12 +3,14 @@ package exec
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/secrets"
 )
 
 func (e *DredgeExec) Log(level api.LogLevel, msg string, args ...interface{}) error {
@@ -20,13 +22,22 @@ func (e *DredgeExec) RequestInput(inputRequests []api.InputRequest) (map[string]
 	inputs := make(map[string]string)
 	var remainingRequests []api.InputRequest
 
+	e.envLock.RLock()
 	for _, inputRequest := range inputRequests {
 		if value, ok := e.Env[inputRequest.Name]; ok {
-			inputs[inputRequest.Name] = fmt.Sprintf("%v", value)
+			if secret, ok := value.(*secrets.Secret); ok {
+				var err error
+				if value, err = secret.Value(); err != nil {
+					e.envLock.RUnlock()
+					return nil, err
+				}
+			}
+			inputs[inputRequest.Name] = inputValue(value)
 		} else {
 			remainingRequests = append(remainingRequests, inputRequest)
 		}
 	}
+	e.envLock.RUnlock()
 
 	if len(remainingRequests) > 0 {
 		remainingInputs, err := e.callbacks.RequestInput(remainingRequests)
@@ -39,9 +50,22 @@ func (e *DredgeExec) RequestInput(inputRequests []api.InputRequest) (map[string]
 		}
 	}
 
+	for _, inputRequest := range inputRequests {
+		if err := inputRequest.ValidateValue(inputs[inputRequest.Name]); err != nil {
+			return nil, err
+		}
+	}
+
 	return inputs, nil
 }
 
+func inputValue(value interface{}) string {
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprintf("%v", value)
+}
+
 func (e *DredgeExec) OpenUrl(url string) error {
 	return e.callbacks.OpenUrl(url)
 }
@@ -70,10 +94,48 @@ func (e *DredgeExec) ExecuteResourceCommand(resourceName string, commandName str
 }
 
 func (e *DredgeExec) SetEnv(name string, value interface{}) error {
+	e.envLock.Lock()
+	defer e.envLock.Unlock()
 	e.Env[name] = value
 	return nil
 }
 
+func (e *DredgeExec) GetEnv(name string) (interface{}, bool) {
+	e.envLock.RLock()
+	defer e.envLock.RUnlock()
+	value, ok := e.Env[name]
+	return value, ok
+}
+
+// Scope returns callbacks for the same Dredgefile with a copy of the
+// environment, extended with vars. Changes to the scope's environment are not
+// visible in the original environment.
+func (e *DredgeExec) Scope(vars map[string]interface{}) api.Callbacks {
+	e.envLock.RLock()
+	env := e.Env.Clone()
+	e.envLock.RUnlock()
+	for key, value := range vars {
+		env[key] = value
+	}
+
+	return &DredgeExec{
+		Parent:              e.Parent,
+		Source:              e.Source,
+		DredgeFile:          e.DredgeFile,
+		Layers:              e.Layers,
+		Origins:             e.Origins,
+		Env:                 env,
+		ResourceDefinitions: e.ResourceDefinitions,
+		Dir:                 e.Dir,
+		profile:             e.profile,
+		callbacks:           e.callbacks,
+	}
+}
+
+// TEMPLATE_FIELD_RE matches the fields used in a template, to find the secrets
+// that need to be resolved.
+var TEMPLATE_FIELD_RE = regexp.MustCompile(`\.([A-Za-z_][A-Za-z0-9_]*)`)
+
 var TEMPLATE_FUNCTIONS = template.FuncMap{
 	"replace": func(s, old, new string) string {
 		return strings.Replace(s, old, new, -1)
@@ -93,18 +155,8 @@ var TEMPLATE_FUNCTIONS = template.FuncMap{
 	"trimSpace": func(s string) string {
 		return strings.TrimSpace(s)
 	},
-	"isTrue":  isTrue,
-	"isFalse": isFalse,
-}
-
-func isTrue(s string) bool {
-	l := strings.ToLower(s)
-	return l == "1" || l == "t" || l == "true" || l == "yes"
-}
-
-func isFalse(s string) bool {
-	l := strings.ToLower(s)
-	return l == "0" || l == "f" || l == "false" || l == "no"
+	"isTrue":  config.IsTrue,
+	"isFalse": config.IsFalse,
 }
 
 func (e *DredgeExec) Template(input string) (string, error) {
@@ -113,8 +165,16 @@ func (e *DredgeExec) Template(input string) (string, error) {
 		return "", fmt.Errorf("failed to parse template: %s", err)
 	}
 
+	e.envLock.RLock()
+	defer e.envLock.RUnlock()
+
+	env, err := e.Env.resolveSecrets(input)
+	if err != nil {
+		return "", err
+	}
+
 	var buffer bytes.Buffer
-	if err := t.Execute(&buffer, e.Env); err != nil {
+	if err := t.Execute(&buffer, env); err != nil {
 		return "", err
 	}
 
@@ -135,14 +195,26 @@ func (e *DredgeExec) AddVariablesToDredgefile(variables map[string]string) error
 		}
 	}
 
-	return validateAndWriteDredgefile(df, rootExec.Source)
+	return rootExec.validateAndWriteDredgefile(df)
 }
 
-func validateAndWriteDredgefile(df *config.DredgeFile, path config.SourcePath) error {
+// validateAndWriteDredgefile writes the Dredgefile without the values of the
+// other layers and merges it again with the layers.
+func (e *DredgeExec) validateAndWriteDredgefile(df *config.DredgeFile) error {
 	if err := df.Validate(); err != nil {
 		return err
 	}
-	return config.WriteDredgeFile(df, path)
+	if err := config.WriteDredgeFile(df, e.Source); err != nil {
+		return err
+	}
+	return e.remergeLayers()
+}
+
+// WriteDredgeFile writes the Dredgefile without the values of the other
+// layers.
+func (e *DredgeExec) WriteDredgeFile() error {
+	rootExec, df := e.getRootExecAndDredgeFile()
+	return config.WriteDredgeFile(df, rootExec.Source)
 }
 
 func (e *DredgeExec) AddWorkflowToDredgefile(w config.Workflow) error {
@@ -157,7 +229,7 @@ func (e *DredgeExec) AddWorkflowToDredgefile(w config.Workflow) error {
 		df.Workflows = append(df.Workflows, w)
 	}
 
-	return validateAndWriteDredgefile(df, rootExec.Source)
+	return rootExec.validateAndWriteDredgefile(df)
 }
 
 func (e *DredgeExec) AddBucketToDredgefile(b config.Bucket) error {
@@ -172,7 +244,7 @@ func (e *DredgeExec) AddBucketToDredgefile(b config.Bucket) error {
 		df.Buckets = append(df.Buckets, b)
 	}
 
-	return validateAndWriteDredgefile(df, rootExec.Source)
+	return rootExec.validateAndWriteDredgefile(df)
 }
 
 func (e *DredgeExec) AddProviderToDredgefile(resource, provider string, providerConfig map[string]string) error {
@@ -185,25 +257,25 @@ func (e *DredgeExec) AddProviderToDredgefile(resource, provider string, provider
 
 	rootExec, df := e.getRootExecAndDredgeFile()
 
-	if e.DredgeFile.Resources == nil {
-		e.DredgeFile.Resources = make(config.Resources)
+	if df.Resources == nil {
+		df.Resources = make(config.Resources)
 	}
 
-	if _, ok := e.DredgeFile.Resources[resource]; !ok {
-		e.DredgeFile.Resources[resource] = []config.ResourceProvider{}
+	if _, ok := df.Resources[resource]; !ok {
+		df.Resources[resource] = []config.ResourceProvider{}
 	}
 
-	for _, p := range e.DredgeFile.Resources[resource] {
+	for _, p := range df.Resources[resource] {
 		if p.Provider == provider {
 			return fmt.Errorf("provider '%s' already defined", provider)
 		}
 	}
 
-	e.DredgeFile.Resources[resource] = append(e.DredgeFile.Resources[resource], config.ResourceProvider{
+	df.Resources[resource] = append(df.Resources[resource], config.ResourceProvider{
 		Provider: provider,
 		Config:   providerConfig,
 	})
-	return validateAndWriteDredgefile(df, rootExec.Source)
+	return rootExec.validateAndWriteDredgefile(df)
 }
 
 func (e *DredgeExec) RelativePathFromDredgefile(path string) (string, error) {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/stretchr/testify/assert"
 )
@@ -198,3 +199,47 @@ func TestAddProviderToDredgefile(t *testing.T) {
 		os.Remove(file)
 	}
 }
+
+func TestScope(t *testing.T) {
+	e := &DredgeExec{
+		Source:     "./Dredgefile",
+		DredgeFile: &config.DredgeFile{},
+		Env:        Env{"name": "world"},
+	}
+
+	scope := e.Scope(map[string]interface{}{"greeting": "hello"})
+
+	output, err := scope.Template("{{ .greeting }} {{ .name }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", output)
+
+	err = scope.SetEnv("name", "scope")
+	assert.Nil(t, err)
+
+	value, ok := e.GetEnv("name")
+	assert.True(t, ok)
+	assert.Equal(t, "world", value)
+	_, ok = e.GetEnv("greeting")
+	assert.False(t, ok)
+
+	value, ok = scope.GetEnv("name")
+	assert.True(t, ok)
+	assert.Equal(t, "scope", value)
+}
+
+func TestRequestInputValidatesEnv(t *testing.T) {
+	e := &DredgeExec{
+		Env: Env{"version": "1.0"},
+	}
+
+	_, err := e.RequestInput([]api.InputRequest{
+		{Name: "version", Pattern: "^v[0-9.]+$"},
+	})
+	assert.Equal(t, "invalid value (1.0) for input version (should match ^v[0-9.]+$)", fmt.Sprint(err))
+
+	inputs, err := e.RequestInput([]api.InputRequest{
+		{Name: "version", Pattern: "^[0-9.]+$"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0", inputs["version"])
+}
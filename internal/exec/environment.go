@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/dredge-dev/dredge/internal/secrets"
 	"github.com/manifoldco/promptui"
 )
 
@@ -24,6 +26,59 @@ func (e Env) AddVariables(v config.Variables) {
 	}
 }
 
+// AddSecrets adds the secrets of a Dredgefile to the environment, secrets are
+// only resolved when they are used. Relative paths in the config of a secret
+// are relative to the Dredgefile.
+func (e Env) AddSecrets(source config.SourcePath, s config.Secrets) error {
+	for name, secret := range s {
+		if _, ok := e[name]; ok {
+			continue
+		}
+		if path := secret.Config["path"]; strings.HasPrefix(path, "./") {
+			resolved, err := resolvePath(MergeSources(source, config.SourcePath(path)))
+			if err != nil {
+				return err
+			}
+			secretConfig := make(map[string]string)
+			for key, value := range secret.Config {
+				secretConfig[key] = value
+			}
+			secretConfig["path"] = resolved
+			secret.Config = secretConfig
+		}
+		value, err := secrets.New(name, secret)
+		if err != nil {
+			return err
+		}
+		e[name] = value
+	}
+	return nil
+}
+
+// resolveSecrets returns the environment with the secrets that are used in
+// the template replaced by their value.
+func (e Env) resolveSecrets(input string) (Env, error) {
+	var resolved Env
+	for _, match := range TEMPLATE_FIELD_RE.FindAllStringSubmatch(input, -1) {
+		secret, ok := e[match[1]].(*secrets.Secret)
+		if !ok {
+			continue
+		}
+		value, err := secret.Value()
+		if err != nil {
+			return nil, err
+		}
+		if resolved == nil {
+			resolved = e.Clone()
+		}
+		resolved[match[1]] = value
+	}
+	if resolved == nil {
+		return e, nil
+	}
+	return resolved, nil
+}
+
 func (e Env) AddInputs(inputs map[string]string) {
 	for key, value := range inputs {
 		e[key] = value
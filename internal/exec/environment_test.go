@@ -2,11 +2,13 @@ package exec
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/stretchr/testify/assert"
 )
@@ -164,3 +166,32 @@ func TestTemplate(t *testing.T) {
 		assert.Equal(t, test.err, err)
 	}
 }
+
+func TestAddSecrets(t *testing.T) {
+	t.Setenv("DRG_TEST_TOKEN", "s3cr3t")
+	env := NewEnv()
+	err := env.AddSecrets("./Dredgefile", config.Secrets{
+		"TOKEN":   {Config: map[string]string{"name": "DRG_TEST_TOKEN"}},
+		"MISSING": {Backend: "env", Config: map[string]string{"name": "DRG_TEST_MISSING"}},
+	})
+	assert.Nil(t, err)
+
+	e := &DredgeExec{Env: env}
+	output, err := e.Template("token={{ .TOKEN }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "token=s3cr3t", output)
+
+	output, err = e.Template("{{ if eq .TOKEN \"s3cr3t\" }}valid{{ end }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "valid", output)
+
+	_, err = e.Template("{{ .MISSING }}")
+	assert.Equal(t, "could not resolve secret MISSING: environment variable DRG_TEST_MISSING is not set", fmt.Sprint(err))
+
+	inputs, err := e.RequestInput([]api.InputRequest{{Name: "TOKEN", Type: api.Text}})
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", inputs["TOKEN"])
+
+	err = env.AddSecrets("./Dredgefile", config.Secrets{"VAULT": {Backend: "vault"}})
+	assert.Equal(t, "unknown backend vault for secret VAULT", fmt.Sprint(err))
+}
@@ -2,6 +2,8 @@ package exec
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
@@ -12,37 +14,71 @@ type DredgeExec struct {
 	Parent              *DredgeExec
 	Source              config.SourcePath
 	DredgeFile          *config.DredgeFile
+	Layers              []Layer
+	Origins             config.Origins
 	Env                 Env
 	ResourceDefinitions []api.ResourceDefinition
+	Dir                 string
+	profile             string
 	callbacks           api.UserInteractionCallbacks
+	envLock             sync.RWMutex
 }
 
 func EmptyExec(source config.SourcePath, rd []api.ResourceDefinition, c api.UserInteractionCallbacks) *DredgeExec {
+	dredgeFile := &config.DredgeFile{}
 	return &DredgeExec{
 		Source:              source,
-		DredgeFile:          &config.DredgeFile{},
+		DredgeFile:          dredgeFile,
+		Layers:              []Layer{{Name: string(source), Path: string(source), DredgeFile: dredgeFile}},
+		Origins:             config.Origins{},
 		Env:                 NewEnv(),
 		ResourceDefinitions: rd,
 		callbacks:           c,
 	}
 }
 
-func NewExec(source config.SourcePath, rd []api.ResourceDefinition, c api.UserInteractionCallbacks) (*DredgeExec, error) {
-	actualSource, dredgeFile, err := ReadDredgeFile(source)
+// NewExec reads the Dredgefile at source and merges it with its layers: the
+// user config, Dredgefile.local and the Dredgefile of the profile.
+func NewExec(source config.SourcePath, profile string, rd []api.ResourceDefinition, c api.UserInteractionCallbacks) (*DredgeExec, error) {
+	return newExec(source, profile, true, rd, c)
+}
+
+// newExec reads the Dredgefile at source, the Dredgefile of the profile is
+// optional when requireProfile is false.
+func newExec(source config.SourcePath, profile string, requireProfile bool, rd []api.ResourceDefinition, c api.UserInteractionCallbacks) (*DredgeExec, error) {
+	actualSource, base, err := ReadDredgeFile(source)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := readLayers(actualSource, base, profile, requireProfile)
+	if err != nil {
+		return nil, err
+	}
+	dredgeFile, origins, err := mergeLayers(layers)
 	if err != nil {
 		return nil, err
 	}
 
 	env := NewEnv()
 	env.AddVariables(dredgeFile.Variables)
+	if err := env.AddSecrets(actualSource, dredgeFile.Secrets); err != nil {
+		return nil, err
+	}
 
-	return &DredgeExec{
+	exec := &DredgeExec{
 		Source:              actualSource,
 		DredgeFile:          dredgeFile,
+		Layers:              layers,
+		Origins:             origins,
 		Env:                 env,
-		ResourceDefinitions: rd,
+		ResourceDefinitions: mergeResourceDefinitions(rd, dredgeFile.ResourceDefinitions),
+		profile:             profile,
 		callbacks:           c,
-	}, nil
+	}
+	if err := exec.validateResourceDefinitions(); err != nil {
+		return nil, err
+	}
+	return exec, nil
 }
 
 func (exec *DredgeExec) Import(source config.SourcePath) (*DredgeExec, error) {
@@ -53,8 +89,13 @@ func (exec *DredgeExec) Import(source config.SourcePath) (*DredgeExec, error) {
 		return nil, err
 	}
 
+	exec.envLock.RLock()
 	env := exec.Env.Clone()
+	exec.envLock.RUnlock()
 	env.AddVariables(imported.Variables)
+	if err := env.AddSecrets(actualSource, imported.Secrets); err != nil {
+		return nil, err
+	}
 
 	return &DredgeExec{
 		Parent:              exec,
@@ -62,6 +103,7 @@ func (exec *DredgeExec) Import(source config.SourcePath) (*DredgeExec, error) {
 		DredgeFile:          imported,
 		Env:                 env,
 		ResourceDefinitions: exec.ResourceDefinitions,
+		Dir:                 exec.Dir,
 		callbacks:           exec.callbacks,
 	}, nil
 }
@@ -69,7 +111,7 @@ func (exec *DredgeExec) Import(source config.SourcePath) (*DredgeExec, error) {
 func (exec *DredgeExec) GetWorkflows() ([]*workflow.Workflow, error) {
 	var workflows []*workflow.Workflow
 	for _, w := range exec.DredgeFile.Workflows {
-		workflow, err := exec.resolveWorkflow(w)
+		workflow, err := exec.resolveWorkflow("", w, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -79,22 +121,28 @@ func (exec *DredgeExec) GetWorkflows() ([]*workflow.Workflow, error) {
 }
 
 func (exec *DredgeExec) GetWorkflow(bucketName, workflowName string) (*workflow.Workflow, error) {
+	return exec.getWorkflow(bucketName, workflowName, nil)
+}
+
+// getWorkflow resolves a workflow, stack contains the workflows that are
+// being resolved and is used to detect cycles in needs.
+func (exec *DredgeExec) getWorkflow(bucketName, workflowName string, stack []string) (*workflow.Workflow, error) {
 	if bucketName == "" {
 		for _, w := range exec.DredgeFile.Workflows {
 			if w.Name == workflowName {
-				return exec.resolveWorkflow(w)
+				return exec.resolveWorkflow("", w, stack)
 			}
 		}
 	} else {
 		for _, b := range exec.DredgeFile.Buckets {
 			if b.Name == bucketName {
-				bucket, err := exec.resolveBucket(b)
+				bucket, de, name, err := exec.resolveBucket(b)
 				if err != nil {
 					return nil, err
 				}
 				for _, w := range bucket.Workflows {
 					if w.Name == workflowName {
-						return exec.resolveWorkflow(w)
+						return de.resolveWorkflow(name, w, stack)
 					}
 				}
 			}
@@ -106,7 +154,7 @@ func (exec *DredgeExec) GetWorkflow(bucketName, workflowName string) (*workflow.
 func (exec *DredgeExec) GetBuckets() ([]*workflow.Bucket, error) {
 	var buckets []*workflow.Bucket
 	for _, b := range exec.DredgeFile.Buckets {
-		bucket, err := exec.resolveBucket(b)
+		bucket, _, _, err := exec.resolveBucket(b)
 		if err != nil {
 			return nil, err
 		}
@@ -116,46 +164,58 @@ func (exec *DredgeExec) GetBuckets() ([]*workflow.Bucket, error) {
 }
 
 func (exec *DredgeExec) GetBucket(bucketName string) (*workflow.Bucket, error) {
+	bucket, _, _, err := exec.getBucket(bucketName)
+	return bucket, err
+}
+
+func (exec *DredgeExec) getBucket(bucketName string) (*workflow.Bucket, *DredgeExec, string, error) {
 	for _, b := range exec.DredgeFile.Buckets {
 		if b.Name == bucketName {
 			return exec.resolveBucket(b)
 		}
 	}
-	return nil, fmt.Errorf("could not find bucket %s", bucketName)
+	return nil, nil, "", fmt.Errorf("could not find bucket %s", bucketName)
 }
 
-func (exec *DredgeExec) resolveBucket(b config.Bucket) (*workflow.Bucket, error) {
+// resolveBucket resolves the imports of a bucket, it also returns the exec of
+// the Dredgefile that declares the workflows of the bucket and the name of the
+// bucket in that Dredgefile, the workflows are resolved in that Dredgefile.
+func (exec *DredgeExec) resolveBucket(b config.Bucket) (*workflow.Bucket, *DredgeExec, string, error) {
 	if b.Import != nil {
 		de := exec
 		if b.Import.Source != "" {
 			var err error
 			de, err = exec.Import(b.Import.Source)
 			if err != nil {
-				return nil, fmt.Errorf("could not load Dredgefile %s: %v", b.Import.Source, err)
+				return nil, nil, "", fmt.Errorf("could not load Dredgefile %s: %v", b.Import.Source, err)
 			}
 		}
-		bucket, err := de.GetBucket(b.Import.Bucket)
+		bucket, declaringExec, name, err := de.getBucket(b.Import.Bucket)
 		if err != nil {
-			return nil, err
+			return nil, nil, "", err
 		}
 		bucket.Name = b.Name
 		if b.Description != "" {
 			bucket.Description = b.Description
 		}
-		return bucket, nil
+		return bucket, declaringExec, name, nil
 	}
 	return &workflow.Bucket{
 		Name:        b.Name,
 		Description: b.Description,
 		Workflows:   b.Workflows,
 		Callbacks:   exec,
-	}, nil
+	}, exec, b.Name, nil
 }
 
 func (exec *DredgeExec) GetWorkflowsInBucket(b *workflow.Bucket) ([]*workflow.Workflow, error) {
+	_, de, name, err := exec.getBucket(b.Name)
+	if err != nil {
+		return nil, err
+	}
 	var workflows []*workflow.Workflow
 	for _, w := range b.Workflows {
-		workflow, err := exec.resolveWorkflow(w)
+		workflow, err := de.resolveWorkflow(name, w, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -164,7 +224,20 @@ func (exec *DredgeExec) GetWorkflowsInBucket(b *workflow.Bucket) ([]*workflow.Wo
 	return workflows, nil
 }
 
-func (exec *DredgeExec) resolveWorkflow(w config.Workflow) (*workflow.Workflow, error) {
+func (exec *DredgeExec) resolveWorkflow(bucketName string, w config.Workflow, stack []string) (*workflow.Workflow, error) {
+	id := workflowId(exec.Source, bucketName, w.Name)
+	for i, s := range stack {
+		if s == id {
+			return nil, fmt.Errorf("dependency cycle in needs: %s", strings.Join(append(stack[i:], id), " -> "))
+		}
+	}
+	stack = append(append([]string{}, stack...), id)
+
+	needs, err := exec.resolveNeeds(w.Needs, stack)
+	if err != nil {
+		return nil, err
+	}
+
 	if w.Import != nil {
 		de := exec
 		if w.Import.Source != "" {
@@ -174,7 +247,7 @@ func (exec *DredgeExec) resolveWorkflow(w config.Workflow) (*workflow.Workflow,
 				return nil, fmt.Errorf("could not load Dredgefile %s: %v", w.Import.Source, err)
 			}
 		}
-		workflow, err := de.GetWorkflow(w.Import.Bucket, w.Import.Workflow)
+		workflow, err := de.getWorkflow(w.Import.Bucket, w.Import.Workflow, stack)
 		if err != nil {
 			return nil, err
 		}
@@ -182,18 +255,42 @@ func (exec *DredgeExec) resolveWorkflow(w config.Workflow) (*workflow.Workflow,
 		if w.Description != "" {
 			workflow.Description = w.Description
 		}
+		workflow.Needs = append(workflow.Needs, needs...)
 		return workflow, nil
 	}
 	return &workflow.Workflow{
+		ID:          id,
 		Name:        w.Name,
 		Description: w.Description,
+		Needs:       needs,
 		Inputs:      w.Inputs,
 		Steps:       w.Steps,
-		Runtimes:    exec.DredgeFile.Runtimes, // TODO I think this breaks with imports
+		Runtimes:    exec.DredgeFile.Runtimes,
 		Callbacks:   exec,
+		Dir:         exec.Dir,
 	}, nil
 }
 
+func (exec *DredgeExec) resolveNeeds(needs []string, stack []string) ([]*workflow.Workflow, error) {
+	var workflows []*workflow.Workflow
+	for _, need := range needs {
+		bucketName, workflowName := config.SplitWorkflowRef(need)
+		w, err := exec.getWorkflow(bucketName, workflowName, stack)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, w)
+	}
+	return workflows, nil
+}
+
+func workflowId(source config.SourcePath, bucketName, workflowName string) string {
+	if bucketName == "" {
+		return fmt.Sprintf("%s:%s", source, workflowName)
+	}
+	return fmt.Sprintf("%s:%s/%s", source, bucketName, workflowName)
+}
+
 func (e *DredgeExec) getRootExec() *DredgeExec {
 	exec := e
 	for exec.Parent != nil {
@@ -204,5 +301,10 @@ func (e *DredgeExec) getRootExec() *DredgeExec {
 
 func (e *DredgeExec) getRootExecAndDredgeFile() (*DredgeExec, *config.DredgeFile) {
 	rootExec := e.getRootExec()
+	for _, layer := range rootExec.Layers {
+		if layer.Path == string(rootExec.Source) {
+			return rootExec, layer.DredgeFile
+		}
+	}
 	return rootExec, rootExec.DredgeFile
 }
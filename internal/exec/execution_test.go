@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/dredge-dev/dredge/internal/api"
 	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
@@ -53,7 +54,7 @@ func TestNewExec(t *testing.T) {
 
 	for testName, test := range tests {
 		t.Logf("Running test case %s", testName)
-		d, err := NewExec(test.source, nil, nil)
+		d, err := NewExec(test.source, "", nil, nil)
 		if test.dredgeFile == nil {
 			assert.Nil(t, d)
 		} else {
@@ -314,3 +315,198 @@ func TestImport(t *testing.T) {
 	assert.Equal(t, "bucket", b.Name)
 	assert.Equal(t, "a bucket of workflows", b.Description)
 }
+
+func TestImportWithNeeds(t *testing.T) {
+	remoteDredgeFilePath := "./test-import-needs.DredgeFile"
+	err := ioutil.WriteFile(remoteDredgeFilePath, []byte(`runtimes:
+- name: go
+  type: container
+  image: golang
+workflows:
+- name: build
+  steps:
+  - shell:
+      cmd: make remote
+buckets:
+- name: b1
+  workflows:
+  - name: test
+    needs: [build]
+    steps:
+    - shell:
+        cmd: make test
+        runtime: go
+`), 0644)
+	assert.Nil(t, err)
+	defer os.Remove(remoteDredgeFilePath)
+
+	de := &DredgeExec{
+		Source: "./Dredgefile",
+		Env:    NewEnv(),
+		DredgeFile: &config.DredgeFile{
+			Workflows: []config.Workflow{
+				{Name: "build", Steps: []config.Step{{Shell: &config.ShellStep{Cmd: "make local"}}}},
+				{Name: "test", Import: &config.ImportWorkflow{Source: config.SourcePath(remoteDredgeFilePath), Bucket: "b1", Workflow: "test"}},
+			},
+			Buckets: []config.Bucket{
+				{Name: "bucket", Import: &config.ImportBucket{Source: config.SourcePath(remoteDredgeFilePath), Bucket: "b1"}},
+			},
+		},
+	}
+
+	w, err := de.GetWorkflow("", "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "make remote", w.Needs[0].Steps[0].Shell.Cmd)
+	assert.Equal(t, "go", w.Runtimes[0].Name)
+
+	w, err = de.GetWorkflow("bucket", "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "make remote", w.Needs[0].Steps[0].Shell.Cmd)
+	assert.Equal(t, "go", w.Runtimes[0].Name)
+
+	b, err := de.GetBucket("bucket")
+	assert.Nil(t, err)
+	workflows, err := de.GetWorkflowsInBucket(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "make remote", workflows[0].Needs[0].Steps[0].Shell.Cmd)
+	assert.Equal(t, "go", workflows[0].Runtimes[0].Name)
+}
+
+func TestGetWorkflowNeeds(t *testing.T) {
+	shell := []config.Step{{Shell: &config.ShellStep{Cmd: "echo"}}}
+	de := &DredgeExec{
+		Source: "./Dredgefile",
+		Env:    NewEnv(),
+		DredgeFile: &config.DredgeFile{
+			Workflows: []config.Workflow{
+				{Name: "build", Steps: shell},
+				{Name: "deploy", Needs: []string{"build", "b1/test"}, Steps: shell},
+				{Name: "release", Needs: []string{"deploy"}, Import: &config.ImportWorkflow{Bucket: "b1", Workflow: "publish"}},
+				{Name: "cycle", Import: &config.ImportWorkflow{Bucket: "b1", Workflow: "cycle"}},
+			},
+			Buckets: []config.Bucket{
+				{
+					Name: "b1",
+					Workflows: []config.Workflow{
+						{Name: "test", Needs: []string{"build"}, Steps: shell},
+						{Name: "publish", Needs: []string{"b1/test"}, Steps: shell},
+						{Name: "cycle", Needs: []string{"cycle"}, Steps: shell},
+					},
+				},
+			},
+		},
+	}
+
+	w, err := de.GetWorkflow("", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(w.Needs))
+	assert.Equal(t, "build", w.Needs[0].Name)
+	assert.Equal(t, "test", w.Needs[1].Name)
+	assert.Equal(t, "build", w.Needs[1].Needs[0].Name)
+	assert.Equal(t, w.Needs[0].ID, w.Needs[1].Needs[0].ID)
+
+	w, err = de.GetWorkflow("", "release")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(w.Needs))
+	assert.Equal(t, "test", w.Needs[0].Name)
+	assert.Equal(t, "deploy", w.Needs[1].Name)
+
+	_, err = de.GetWorkflow("", "cycle")
+	assert.Equal(t, "dependency cycle in needs: ./Dredgefile:cycle -> ./Dredgefile:b1/cycle -> ./Dredgefile:cycle", fmt.Sprint(err))
+}
+
+func TestNewExecResourceDefinitions(t *testing.T) {
+	defaults := []api.ResourceDefinition{
+		{
+			Name:   "release",
+			Fields: []api.Field{{Name: "name", Type: "string"}},
+			Commands: []api.Command{
+				{Name: "get", OutputType: "[]release"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		definitions []config.ResourceDefinition
+		result      []api.ResourceDefinition
+		errMsg      string
+	}{
+		"new definition": {
+			definitions: []config.ResourceDefinition{
+				{
+					Name: "incident",
+					Fields: []config.ResourceField{
+						{Name: "name"},
+						{Name: "opened", Type: "date"},
+					},
+					Commands: []config.ResourceCommand{
+						{Name: "get", OutputType: "[]incident"},
+						{Name: "create", Inputs: []config.Input{{Name: "title"}}, OutputType: "incident"},
+					},
+				},
+			},
+			result: []api.ResourceDefinition{
+				defaults[0],
+				{
+					Name: "incident",
+					Fields: []api.Field{
+						{Name: "name", Type: "string"},
+						{Name: "opened", Type: "date"},
+					},
+					Commands: []api.Command{
+						{Name: "get", OutputType: "[]incident"},
+						{Name: "create", Inputs: []api.InputRequest{{Name: "title", Type: api.Text}}, OutputType: "incident"},
+					},
+				},
+			},
+		},
+		"override default": {
+			definitions: []config.ResourceDefinition{
+				{
+					Name:   "release",
+					Fields: []config.ResourceField{{Name: "version"}},
+					Commands: []config.ResourceCommand{
+						{Name: "describe", OutputType: "object"},
+					},
+				},
+			},
+			result: []api.ResourceDefinition{
+				{
+					Name:   "release",
+					Fields: []api.Field{{Name: "version", Type: "string"}},
+					Commands: []api.Command{
+						{Name: "describe", OutputType: "object"},
+					},
+				},
+			},
+		},
+		"unknown output type": {
+			definitions: []config.ResourceDefinition{
+				{
+					Name: "incident",
+					Commands: []config.ResourceCommand{
+						{Name: "get", OutputType: "[]ticket"},
+					},
+				},
+			},
+			errMsg: "resource definition incident: command get: invalid output type []ticket: could not find resource definition for ticket",
+		},
+	}
+
+	tmpFile := fmt.Sprintf("./tmp-test-%d", rand.Intn(100000))
+	defer os.Remove(tmpFile)
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		os.Remove(tmpFile)
+		err := config.WriteDredgeFile(&config.DredgeFile{ResourceDefinitions: test.definitions}, config.SourcePath(tmpFile))
+		assert.Nil(t, err)
+		d, err := NewExec(config.SourcePath(tmpFile), "", defaults, nil)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.result, d.ResourceDefinitions)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
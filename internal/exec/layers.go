@@ -0,0 +1,145 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	UserConfigName        = "~/.dredge/config"
+	LocalDredgefileSuffix = ".local"
+	ProfileEnv            = "DRG_PROFILE"
+)
+
+// Layer is a file in the merge chain of the Dredgefile. The layers are merged
+// in order: ~/.dredge/config, the Dredgefile, Dredgefile.local and
+// Dredgefile.<profile>, so the values in later layers override earlier ones.
+type Layer struct {
+	Name       string
+	Path       string
+	DredgeFile *config.DredgeFile
+}
+
+// readLayers returns the layers of the Dredgefile at source, only local
+// Dredgefiles have layers. A missing Dredgefile of the profile is an error
+// when requireProfile is true.
+func readLayers(source config.SourcePath, dredgeFile *config.DredgeFile, profile string, requireProfile bool) ([]Layer, error) {
+	s := string(source)
+	if !strings.HasPrefix(s, "./") {
+		return []Layer{{Name: s, Path: s, DredgeFile: dredgeFile}}, nil
+	}
+
+	var layers []Layer
+	userConfig, err := readUserConfig()
+	if err != nil {
+		return nil, err
+	}
+	if userConfig != nil {
+		layers = append(layers, *userConfig)
+	}
+	layers = append(layers, Layer{Name: s, Path: s, DredgeFile: dredgeFile})
+
+	local := s + LocalDredgefileSuffix
+	localDredgeFile, err := readLayer(local)
+	if err != nil {
+		return nil, err
+	}
+	if localDredgeFile != nil {
+		layers = append(layers, Layer{Name: local, Path: local, DredgeFile: localDredgeFile})
+	}
+
+	if profile != "" {
+		path := s + "." + profile
+		profileDredgeFile, err := readLayer(path)
+		if err != nil {
+			return nil, err
+		}
+		if profileDredgeFile == nil && requireProfile {
+			return nil, fmt.Errorf("could not find %s for profile %s", path, profile)
+		}
+		if profileDredgeFile != nil {
+			layers = append(layers, Layer{Name: path, Path: path, DredgeFile: profileDredgeFile})
+		}
+	}
+	return layers, nil
+}
+
+// readUserConfig reads ~/.dredge/config, relative paths of secrets are
+// relative to ~/.dredge.
+func readUserConfig() (*Layer, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	path := filepath.Join(home, UserConfigName[2:])
+	dredgeFile, err := readLayer(path)
+	if err != nil || dredgeFile == nil {
+		return nil, err
+	}
+	for name, secret := range dredgeFile.Secrets {
+		if p := secret.Config["path"]; strings.HasPrefix(p, "./") {
+			secret.Config["path"] = filepath.Join(filepath.Dir(path), p)
+			dredgeFile.Secrets[name] = secret
+		}
+	}
+	return &Layer{Name: UserConfigName, Path: path, DredgeFile: dredgeFile}, nil
+}
+
+// readLayer reads a Dredgefile that is merged with other layers, it's only
+// validated after merging, as it can refer to values in other layers. A
+// missing file results in nil.
+func readLayer(path string) (*config.DredgeFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	dredgeFile := &config.DredgeFile{}
+	if err := yaml.Unmarshal(content, dredgeFile); err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+	return dredgeFile, nil
+}
+
+// mergeLayers merges the layers and returns the merged Dredgefile and the
+// origins of its values.
+func mergeLayers(layers []Layer) (*config.DredgeFile, config.Origins, error) {
+	origins := make(config.Origins)
+	merged := layers[0].DredgeFile
+	merged.AddOrigins(layers[0].Name, origins)
+	for _, layer := range layers[1:] {
+		merged = merged.Merge(layer.DredgeFile, layer.Name, origins)
+	}
+	if len(layers) > 1 {
+		if err := merged.Validate(); err != nil {
+			var names []string
+			for _, layer := range layers {
+				names = append(names, layer.Name)
+			}
+			return nil, nil, fmt.Errorf("invalid Dredgefile after merging %s: %v", strings.Join(names, ", "), err)
+		}
+	}
+	return merged, origins, nil
+}
+
+// remergeLayers merges the layers again after the Dredgefile was changed.
+func (e *DredgeExec) remergeLayers() error {
+	if len(e.Layers) <= 1 {
+		return nil
+	}
+	merged, origins, err := mergeLayers(e.Layers)
+	if err != nil {
+		return err
+	}
+	*e.DredgeFile = *merged
+	e.Origins = origins
+	return nil
+}
@@ -0,0 +1,127 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func inLayersDir(t *testing.T, files map[string]string) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	project := filepath.Join(dir, "project")
+	assert.Nil(t, os.MkdirAll(filepath.Join(home, ".dredge"), 0755))
+	assert.Nil(t, os.MkdirAll(project, 0755))
+	t.Setenv("HOME", home)
+	for name, content := range files {
+		path := filepath.Join(project, name)
+		if name == UserConfigName {
+			path = filepath.Join(home, ".dredge", "config")
+		}
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(project))
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+	})
+}
+
+func TestNewExecWithLayers(t *testing.T) {
+	inLayersDir(t, map[string]string{
+		UserConfigName: `variables:
+  EDITOR: vim
+  PORT: "3000"
+`,
+		"Dredgefile": `variables:
+  PORT: "8080"
+  IMAGE: app:latest
+workflows:
+- name: build
+  steps:
+  - shell:
+      cmd: make
+- name: test
+  needs: [build]
+  steps:
+  - shell:
+      cmd: make test
+`,
+		"Dredgefile.local": `variables:
+  PORT: "9090"
+workflows:
+- name: test
+  needs: [build]
+  description: Run the tests locally
+  steps:
+  - shell:
+      cmd: make test-local
+`,
+		"Dredgefile.prod": `variables:
+  IMAGE: app:1.0
+`,
+	})
+
+	de, err := NewExec("./Dredgefile", "prod", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "vim", de.Env["EDITOR"])
+	assert.Equal(t, "9090", de.Env["PORT"])
+	assert.Equal(t, "app:1.0", de.Env["IMAGE"])
+	assert.Equal(t, []string{UserConfigName, "./Dredgefile", "./Dredgefile.local", "./Dredgefile.prod"}, layerNames(de))
+	assert.Equal(t, "./Dredgefile.local", de.Origins["variables.PORT"])
+	assert.Equal(t, "./Dredgefile.prod", de.Origins["variables.IMAGE"])
+	assert.Equal(t, "./Dredgefile.local", de.Origins["workflows.test"])
+
+	w, err := de.GetWorkflow("", "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "Run the tests locally", w.Description)
+
+	err = de.AddWorkflowToDredgefile(config.Workflow{Name: "lint", Steps: []config.Step{{Shell: &config.ShellStep{Cmd: "make lint"}}}})
+	assert.Nil(t, err)
+	_, written, err := ReadDredgeFile("./Dredgefile")
+	assert.Nil(t, err)
+	assert.Equal(t, config.Variables{"PORT": "8080", "IMAGE": "app:latest"}, written.Variables)
+	assert.Equal(t, 3, len(written.Workflows))
+	assert.Equal(t, "", written.Workflows[1].Description)
+	_, err = de.GetWorkflow("", "lint")
+	assert.Nil(t, err)
+	assert.Equal(t, "./Dredgefile", de.Origins["workflows.lint"])
+
+	_, err = NewExec("./Dredgefile", "staging", nil, nil)
+	assert.Equal(t, "could not find ./Dredgefile.staging for profile staging", fmt.Sprint(err))
+}
+
+func TestNewExecWithInvalidLayers(t *testing.T) {
+	inLayersDir(t, map[string]string{
+		"Dredgefile": `workflows:
+- name: build
+  steps:
+  - shell:
+      cmd: make
+`,
+		"Dredgefile.local": `workflows:
+- name: test
+  needs: [lint]
+  steps:
+  - shell:
+      cmd: make test
+`,
+	})
+
+	_, err := NewExec("./Dredgefile", "", nil, nil)
+	assert.Equal(t, "invalid Dredgefile after merging ./Dredgefile, ./Dredgefile.local: workflow test: could not find workflow lint in needs", fmt.Sprint(err))
+}
+
+func layerNames(de *DredgeExec) []string {
+	var names []string
+	for _, layer := range de.Layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
@@ -0,0 +1,260 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osExec "os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const LockFileName = DefaultDredgefileName + ".lock"
+
+var COMMIT_RE = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// LockFile pins the remote imports to the version their ref resolved to, so
+// the imports don't change until they are updated with drg update-imports.
+type LockFile struct {
+	Imports map[string]LockedImport `yaml:"imports"`
+}
+
+// LockedImport pins a git repo to a commit, a download to its sha256 or an
+// OCI tag to a digest.
+type LockedImport struct {
+	Repo   string `yaml:"repo"`
+	Ref    string `yaml:"ref,omitempty"`
+	Commit string `yaml:"commit,omitempty"`
+	Sha256 string `yaml:"sha256,omitempty"`
+	Digest string `yaml:"digest,omitempty"`
+}
+
+// ImportUpdate is an import of which the pinned commit changed, From is empty
+// for an import that wasn't pinned before.
+type ImportUpdate struct {
+	Import string
+	From   string
+	To     string
+}
+
+func (locked LockedImport) version() string {
+	switch {
+	case locked.Sha256 != "":
+		return ChecksumPrefix + locked.Sha256
+	case locked.Digest != "":
+		return locked.Digest
+	}
+	return locked.Commit
+}
+
+func importKey(repo, ref string) string {
+	if ref == "" {
+		return repo
+	}
+	return repo + "@" + ref
+}
+
+// ReadLockFile reads the lock file next to the Dredgefile, a missing lock
+// file is an empty lock file.
+func ReadLockFile() (*LockFile, error) {
+	lock := &LockFile{}
+	content, err := ioutil.ReadFile(LockFileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", LockFileName, err)
+	}
+	if lock.Imports == nil {
+		lock.Imports = make(map[string]LockedImport)
+	}
+	return lock, nil
+}
+
+func (lock *LockFile) Write() error {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(LockFileName, content, 0644)
+}
+
+// UpdateImports resolves the refs of the pinned imports to their latest
+// commit and pins the imports of the workflows and buckets that aren't pinned
+// yet.
+func (e *DredgeExec) UpdateImports() ([]ImportUpdate, error) {
+	before, err := ReadLockFile()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := ReadLockFile()
+	if err != nil {
+		return nil, err
+	}
+	for key, locked := range lock.Imports {
+		if lock.Imports[key], err = updateImport(key, locked); err != nil {
+			return nil, err
+		}
+	}
+	if err := lock.Write(); err != nil {
+		return nil, err
+	}
+
+	if err := e.loadImports(); err != nil {
+		return nil, err
+	}
+
+	after, err := ReadLockFile()
+	if err != nil {
+		return nil, err
+	}
+	var updates []ImportUpdate
+	for key, locked := range after.Imports {
+		from, to := before.Imports[key].version(), locked.version()
+		if from != to {
+			updates = append(updates, ImportUpdate{Import: key, From: from, To: to})
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Import < updates[j].Import
+	})
+	return updates, nil
+}
+
+// updateImport returns the import pinned to the latest version of its ref.
+func updateImport(key string, locked LockedImport) (LockedImport, error) {
+	var err error
+	switch {
+	case locked.Sha256 != "":
+		locked.Sha256, err = download(locked.Repo, downloadPath(locked.Repo), "")
+	case locked.Digest != "":
+		locked.Digest, err = oras("resolve", locked.Repo+":"+locked.Ref)
+	default:
+		repoPath := resolveRepoPath(key)
+		cloned, err := cloneRepo(locked.Repo, repoPath)
+		if err != nil {
+			return locked, err
+		}
+		if !cloned {
+			if err := fetchRepo(repoPath); err != nil {
+				return locked, err
+			}
+		}
+		locked.Commit, err = resolveCommit(repoPath, locked.Ref)
+		if err != nil {
+			return locked, fmt.Errorf("could not resolve %s: %v", key, err)
+		}
+	}
+	return locked, err
+}
+
+// loadImports resolves all workflows and buckets, which checks out the pinned
+// commit of every import and pins the imports that aren't pinned yet.
+func (e *DredgeExec) loadImports() error {
+	if _, err := e.GetWorkflows(); err != nil {
+		return err
+	}
+	buckets, err := e.GetBuckets()
+	if err != nil {
+		return err
+	}
+	for _, b := range buckets {
+		if _, err := e.GetWorkflowsInBucket(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneRepo clones the repo when it isn't cloned yet, it returns true when the
+// repo was cloned.
+func cloneRepo(repo, repoPath string) (bool, error) {
+	if _, err := os.Stat(repoPath); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(LocalDredgeRepoStorage, 0755); err != nil {
+		return false, err
+	}
+	if _, err := git("", "clone", "--quiet", repo, repoPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func fetchRepo(repoPath string) error {
+	_, err := git(repoPath, "fetch", "--quiet", "--tags", "--force", "origin")
+	return err
+}
+
+// resolveCommit returns the commit of a branch, tag or commit, the default
+// branch when the ref is empty.
+func resolveCommit(repoPath, ref string) (string, error) {
+	candidates := []string{"refs/remotes/origin/HEAD"}
+	if ref != "" {
+		candidates = []string{"refs/remotes/origin/" + ref, "refs/tags/" + ref, ref}
+	}
+	for _, candidate := range candidates {
+		commit, err := git(repoPath, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a branch, tag or commit %s", ref)
+}
+
+// checkoutPinned checks out the pinned commit of an import and verifies the
+// checkout matches the lock file.
+func checkoutPinned(repoPath, key string, locked LockedImport) error {
+	if !COMMIT_RE.MatchString(locked.Commit) {
+		return fmt.Errorf("invalid commit %s for %s in %s", locked.Commit, key, LockFileName)
+	}
+	if COMMIT_RE.MatchString(locked.Ref) && locked.Ref != locked.Commit {
+		return fmt.Errorf("%s is pinned to %s in %s, run drg update-imports", key, locked.Commit, LockFileName)
+	}
+	status, err := git(repoPath, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return fmt.Errorf("%s has local changes in %s, remove the directory to clone it again", key, repoPath)
+	}
+	if head, _ := git(repoPath, "rev-parse", "HEAD"); head != locked.Commit {
+		if _, err := git(repoPath, "checkout", "--quiet", "--detach", locked.Commit); err != nil {
+			if err := fetchRepo(repoPath); err != nil {
+				return err
+			}
+			if _, err := git(repoPath, "checkout", "--quiet", "--detach", locked.Commit); err != nil {
+				return fmt.Errorf("could not find commit %s of %s pinned in %s, run drg update-imports", locked.Commit, key, LockFileName)
+			}
+		}
+	}
+	head, err := git(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if head != locked.Commit {
+		return fmt.Errorf("%s is at %s, but %s is pinned in %s", key, head, locked.Commit, LockFileName)
+	}
+	return nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	return run(dir, "git", args...)
+}
+
+func run(dir, name string, args ...string) (string, error) {
+	cmd := osExec.Command(name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s failed: %v %s", name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,143 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRef(t *testing.T) {
+	tests := map[string]struct {
+		source string
+		repo   string
+		ref    string
+	}{
+		"without ref": {
+			source: "https://github.com/dredge-dev/dredge.git",
+			repo:   "https://github.com/dredge-dev/dredge.git",
+		},
+		"with tag": {
+			source: "https://github.com/dredge-dev/dredge.git@v1.0.0",
+			repo:   "https://github.com/dredge-dev/dredge.git",
+			ref:    "v1.0.0",
+		},
+		"user in url": {
+			source: "https://user@github.com/dredge-dev/dredge.git",
+			repo:   "https://user@github.com/dredge-dev/dredge.git",
+		},
+		"scp-like url": {
+			source: "git@github.com:dredge-dev/dredge.git",
+			repo:   "git@github.com:dredge-dev/dredge.git",
+		},
+		"scp-like url with ref": {
+			source: "git@github.com:dredge-dev/dredge.git@main",
+			repo:   "git@github.com:dredge-dev/dredge.git",
+			ref:    "main",
+		},
+		"default repo with ref": {
+			source: "@v1.0.0",
+			repo:   DefaultDredgeRepo,
+			ref:    "v1.0.0",
+		},
+	}
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		repo, ref := splitRef(test.source)
+		assert.Equal(t, test.repo, repo)
+		assert.Equal(t, test.ref, ref)
+	}
+}
+
+func TestPinnedImports(t *testing.T) {
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "origin")
+	gitCommit(t, origin, "v1")
+	_, err := git(origin, "tag", "v1")
+	assert.Nil(t, err)
+	v1, err := git(origin, "rev-parse", "HEAD")
+	assert.Nil(t, err)
+	gitCommit(t, origin, "v2")
+	v2, err := git(origin, "rev-parse", "HEAD")
+	assert.Nil(t, err)
+
+	project := filepath.Join(dir, "project")
+	assert.Nil(t, os.Mkdir(project, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(project, "Dredgefile"), []byte(fmt.Sprintf(`workflows:
+- name: tagged
+  import:
+    source: %[1]s@v1:Dredgefile
+    workflow: hello
+- name: latest
+  import:
+    source: %[1]s:Dredgefile
+    workflow: hello
+`, origin)), 0644))
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(project))
+	defer os.Chdir(cwd)
+
+	de, err := NewExec("./Dredgefile", "", nil, nil)
+	assert.Nil(t, err)
+	assertImportedHello(t, de, "tagged", "echo v1")
+	assertImportedHello(t, de, "latest", "echo v2")
+
+	lock, err := ReadLockFile()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]LockedImport{
+		origin + "@v1": {Repo: origin, Ref: "v1", Commit: v1},
+		origin:         {Repo: origin, Commit: v2},
+	}, lock.Imports)
+
+	gitCommit(t, origin, "v3")
+	v3, err := git(origin, "rev-parse", "HEAD")
+	assert.Nil(t, err)
+	assertImportedHello(t, de, "latest", "echo v2")
+
+	updates, err := de.UpdateImports()
+	assert.Nil(t, err)
+	assert.Equal(t, []ImportUpdate{{Import: origin, From: v2, To: v3}}, updates)
+	assertImportedHello(t, de, "latest", "echo v3")
+	assertImportedHello(t, de, "tagged", "echo v1")
+
+	updates, err = de.UpdateImports()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(updates))
+
+	lock.Imports[origin] = LockedImport{Repo: origin, Commit: "0000000000000000000000000000000000000000"}
+	assert.Nil(t, lock.Write())
+	_, err = de.GetWorkflow("", "latest")
+	assert.Equal(t, fmt.Sprintf("could not load Dredgefile %[1]s:Dredgefile: could not find commit 0000000000000000000000000000000000000000 of %[1]s pinned in Dredgefile.lock, run drg update-imports", origin), fmt.Sprint(err))
+
+	lock.Imports[origin] = LockedImport{Repo: origin, Commit: v3}
+	assert.Nil(t, lock.Write())
+	repoPath := resolveRepoPath(origin)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(repoPath, "Dredgefile"), []byte("workflows: []\n"), 0644))
+	_, err = de.GetWorkflow("", "latest")
+	assert.Equal(t, fmt.Sprintf("could not load Dredgefile %[1]s:Dredgefile: %[1]s has local changes in %[2]s, remove the directory to clone it again", origin, repoPath), fmt.Sprint(err))
+}
+
+func gitCommit(t *testing.T, repo, version string) {
+	if _, err := os.Stat(repo); err != nil {
+		_, err := git("", "init", "--quiet", repo)
+		assert.Nil(t, err)
+	}
+	content := fmt.Sprintf("workflows:\n- name: hello\n  steps:\n  - shell:\n      cmd: echo %s\n", version)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(repo, "Dredgefile"), []byte(content), 0644))
+	_, err := git(repo, "add", "Dredgefile")
+	assert.Nil(t, err)
+	_, err = git(repo, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", version)
+	assert.Nil(t, err)
+}
+
+func assertImportedHello(t *testing.T, de *DredgeExec, name, cmd string) {
+	w, err := de.GetWorkflow("", name)
+	assert.Nil(t, err)
+	if assert.NotNil(t, w) {
+		assert.Equal(t, cmd, w.Steps[0].Shell.Cmd)
+	}
+}
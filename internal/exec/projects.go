@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+// Project is a directory with a Dredgefile that matches one of the projects
+// globs of the root Dredgefile. The workflows of a project run in its
+// directory.
+type Project struct {
+	Name string
+	Dir  string
+	Exec *DredgeExec
+}
+
+// FindDredgefile returns the nearest directory with a Dredgefile, starting at
+// dir and walking up the directory tree.
+func FindDredgefile(dir string) (string, error) {
+	start := dir
+	for {
+		info, err := os.Stat(filepath.Join(dir, DefaultDredgefileName))
+		if err == nil && info.Mode().IsRegular() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("could not find a %s in %s or its parent directories", DefaultDredgefileName, start)
+		}
+		dir = parent
+	}
+}
+
+// GetProjects returns the projects of the Dredgefile, the name of a project is
+// the name of its directory. A project doesn't need a Dredgefile for the
+// profile of the root Dredgefile.
+func (e *DredgeExec) GetProjects() ([]*Project, error) {
+	baseDir := filepath.Dir(string(e.Source))
+	var projects []*Project
+	dirs := make(map[string]string)
+	for _, pattern := range e.DredgeFile.Projects {
+		matches, err := filepath.Glob(filepath.Join(baseDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid project pattern %s: %v", pattern, err)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(filepath.Join(match, DefaultDredgefileName))
+			if err != nil || !info.Mode().IsRegular() || filepath.Clean(match) == filepath.Clean(baseDir) {
+				continue
+			}
+			name := filepath.Base(match)
+			if dir, ok := dirs[name]; ok {
+				if dir == match {
+					continue
+				}
+				return nil, fmt.Errorf("project %s is defined by %s and %s", name, dir, match)
+			}
+			dirs[name] = match
+
+			source := config.SourcePath("./" + filepath.ToSlash(filepath.Join(match, DefaultDredgefileName)))
+			de, err := newExec(source, e.profile, false, e.ResourceDefinitions, e.callbacks)
+			if err != nil {
+				return nil, fmt.Errorf("could not load project %s: %v", name, err)
+			}
+			de.Dir, err = filepath.Abs(match)
+			if err != nil {
+				return nil, err
+			}
+			projects = append(projects, &Project{Name: name, Dir: de.Dir, Exec: de})
+		}
+	}
+	return projects, nil
+}
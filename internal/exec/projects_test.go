@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const PROJECT_DREDGEFILE = `workflows:
+- name: build
+  steps:
+  - shell:
+      cmd: make
+`
+
+func TestFindDredgefile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "services", "api", "src")
+	assert.Nil(t, os.MkdirAll(sub, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dredgefile"), []byte(PROJECT_DREDGEFILE), 0644))
+
+	found, err := FindDredgefile(sub)
+	assert.Nil(t, err)
+	assert.Equal(t, dir, found)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "services", "api", "Dredgefile"), []byte(PROJECT_DREDGEFILE), 0644))
+	found, err = FindDredgefile(sub)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "services", "api"), found)
+
+	assert.Nil(t, os.Remove(filepath.Join(dir, "Dredgefile")))
+	_, err = FindDredgefile(dir)
+	assert.Equal(t, fmt.Sprintf("could not find a Dredgefile in %s or its parent directories", dir), fmt.Sprint(err))
+}
+
+func TestGetProjects(t *testing.T) {
+	inLayersDir(t, map[string]string{
+		"Dredgefile": `projects:
+- services/*
+- tools/cli
+- services/api
+workflows:
+- name: build
+  steps:
+  - shell:
+      cmd: make
+`,
+	})
+	for _, dir := range []string{"services/api", "services/web", "services/docs", "tools/cli"} {
+		assert.Nil(t, os.MkdirAll(dir, 0755))
+		if dir != "services/docs" {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dredgefile"), []byte(PROJECT_DREDGEFILE), 0644))
+		}
+	}
+
+	de, err := NewExec("./Dredgefile", "", nil, nil)
+	assert.Nil(t, err)
+	projects, err := de.GetProjects()
+	assert.Nil(t, err)
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"api", "web", "cli"}, names)
+
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(cwd, "services", "api"), projects[0].Dir)
+	assert.Equal(t, "./services/api/Dredgefile", string(projects[0].Exec.Source))
+	w, err := projects[0].Exec.GetWorkflow("", "build")
+	assert.Nil(t, err)
+	assert.Equal(t, projects[0].Dir, w.Dir)
+
+	assert.Nil(t, os.MkdirAll("tools/api", 0755))
+	assert.Nil(t, ioutil.WriteFile("tools/api/Dredgefile", []byte(PROJECT_DREDGEFILE), 0644))
+	de.DredgeFile.Projects = []string{"services/*", "tools/*"}
+	_, err = de.GetProjects()
+	assert.Equal(t, "project api is defined by services/api and tools/api", fmt.Sprint(err))
+}
+
+func TestGetProjectsWithProfile(t *testing.T) {
+	inLayersDir(t, map[string]string{
+		"Dredgefile": `projects:
+- services/*
+`,
+		"Dredgefile.prod": `variables:
+  ENV: prod
+`,
+	})
+	for _, dir := range []string{"services/api", "services/web"} {
+		assert.Nil(t, os.MkdirAll(dir, 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dredgefile"), []byte(PROJECT_DREDGEFILE), 0644))
+	}
+	assert.Nil(t, ioutil.WriteFile("services/api/Dredgefile.prod", []byte("variables:\n  REPLICAS: \"3\"\n"), 0644))
+
+	de, err := NewExec("./Dredgefile", "prod", nil, nil)
+	assert.Nil(t, err)
+	projects, err := de.GetProjects()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(projects))
+	assert.Equal(t, "3", projects[0].Exec.Env["REPLICAS"])
+	assert.Equal(t, []string{"./services/api/Dredgefile", "./services/api/Dredgefile.prod"}, layerNames(projects[0].Exec))
+	assert.Equal(t, []string{"./services/web/Dredgefile"}, layerNames(projects[1].Exec))
+
+	assert.Nil(t, os.Remove("Dredgefile.prod"))
+	_, err = NewExec("./Dredgefile", "prod", nil, nil)
+	assert.Equal(t, "could not find ./Dredgefile.prod for profile prod", fmt.Sprint(err))
+}
@@ -0,0 +1,206 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	osExec "os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const ChecksumPrefix = "sha256="
+
+var SHA256_RE = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+var httpClient = http.DefaultClient
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+func resolveFile(source string) (string, error) {
+	p := source[len(FileScheme):]
+	if !filepath.IsAbs(p) {
+		return "", fmt.Errorf("invalid source %s (file:// needs an absolute path, use ./ for a path relative to the Dredgefile)", source)
+	}
+	return p, nil
+}
+
+// resolveHttps downloads the file once and verifies it against the checksum
+// of the source or, without a checksum, the checksum pinned in the lock file.
+func resolveHttps(source string) (string, error) {
+	u, checksum, err := splitChecksum(source)
+	if err != nil {
+		return "", err
+	}
+	// <repo>:<path> sources of repos without a .git suffix were git imports
+	// before https downloads, they need the git+ prefix now
+	parts := strings.SplitN(u[len(HttpsScheme):], "/", 2)
+	if len(parts) == 2 && strings.Contains(parts[1], ":") {
+		return "", fmt.Errorf("ambiguous source %s (use %s%s to import a path from a git repo)", source, GitScheme, source)
+	}
+	p := downloadPath(u)
+	pinned := false
+	if checksum == "" {
+		lock, err := ReadLockFile()
+		if err != nil {
+			return "", err
+		}
+		locked, ok := lock.Imports[u]
+		if !ok {
+			sum, err := download(u, p, "")
+			if err != nil {
+				return "", err
+			}
+			lock.Imports[u] = LockedImport{Repo: u, Sha256: sum}
+			return p, lock.Write()
+		}
+		checksum, pinned = locked.Sha256, true
+	}
+	if sum, err := fileChecksum(p); err == nil && sum == checksum {
+		return p, nil
+	}
+	sum, err := download(u, p, checksum)
+	if errors.Is(err, errChecksumMismatch) {
+		if pinned {
+			return "", fmt.Errorf("checksum mismatch for %s: expected sha256 %s pinned in %s, got %s, run drg update-imports", u, checksum, LockFileName, sum)
+		}
+		return "", fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", u, checksum, sum)
+	}
+	return p, err
+}
+
+func splitChecksum(source string) (string, string, error) {
+	i := strings.Index(source, "#")
+	if i < 0 {
+		return source, "", nil
+	}
+	fragment := source[i+1:]
+	checksum := strings.ToLower(strings.TrimPrefix(fragment, ChecksumPrefix))
+	if !strings.HasPrefix(fragment, ChecksumPrefix) || !SHA256_RE.MatchString(checksum) {
+		return "", "", fmt.Errorf("invalid checksum %s in source %s (expected #%s<64 hex characters>)", fragment, source, ChecksumPrefix)
+	}
+	return source[:i], checksum, nil
+}
+
+func downloadPath(u string) string {
+	name := DefaultDredgefileName
+	if parsed, err := url.Parse(u); err == nil {
+		if base := path.Base(parsed.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+	return filepath.Join(LocalDredgeHttpStorage, hashString(u), name)
+}
+
+// download downloads the url to the path and returns its checksum, when the
+// checksum isn't empty the download is only written when it matches.
+func download(u, p, checksum string) (string, error) {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return "", fmt.Errorf("could not download %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not download %s: %s", u, resp.Status)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not download %s: %v", u, err)
+	}
+	hash := sha256.Sum256(content)
+	sum := hex.EncodeToString(hash[:])
+	if checksum != "" && sum != checksum {
+		return sum, errChecksumMismatch
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	return sum, ioutil.WriteFile(p, content, 0644)
+}
+
+func fileChecksum(p string) (string, error) {
+	content, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// resolveOci pulls the artifact with oras once per digest, a tag is pinned to
+// its digest in the lock file.
+func resolveOci(source string) (string, error) {
+	reference, subPath := source[len(OciScheme):], ""
+	if i := strings.Index(reference, "//"); i >= 0 {
+		reference, subPath = reference[:i], reference[i+2:]
+	}
+	if reference == "" {
+		return "", fmt.Errorf("missing reference in source %s (expected %s<registry>/<repository>[:tag|@digest][//<path>])", source, OciScheme)
+	}
+	repo, tag, digest := splitOciReference(reference)
+	if digest == "" {
+		key := OciScheme + repo + ":" + tag
+		lock, err := ReadLockFile()
+		if err != nil {
+			return "", err
+		}
+		locked, ok := lock.Imports[key]
+		if !ok {
+			digest, err = oras("resolve", repo+":"+tag)
+			if err != nil {
+				return "", err
+			}
+			locked = LockedImport{Repo: repo, Ref: tag, Digest: digest}
+			lock.Imports[key] = locked
+			if err := lock.Write(); err != nil {
+				return "", err
+			}
+		}
+		digest = locked.Digest
+	}
+
+	pinned := repo + "@" + digest
+	dir := filepath.Join(LocalDredgeOciStorage, hashString(pinned))
+	if _, err := os.Stat(dir); err != nil {
+		// Pull in a temporary directory, so a failed pull isn't taken for a
+		// cached artifact
+		tmp := dir + ".tmp"
+		if err := os.RemoveAll(tmp); err != nil {
+			return "", err
+		}
+		if _, err := oras("pull", "--output", tmp, pinned); err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, subPath), nil
+}
+
+// splitOciReference splits a reference in the repository and the tag or
+// digest, the tag is latest when the reference has neither.
+func splitOciReference(reference string) (string, string, string) {
+	if i := strings.Index(reference, "@"); i >= 0 {
+		return reference[:i], "", reference[i+1:]
+	}
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:], ""
+	}
+	return reference, "latest", ""
+}
+
+func oras(args ...string) (string, error) {
+	if _, err := osExec.LookPath("oras"); err != nil {
+		return "", fmt.Errorf("oras is required for %s sources, see https://oras.land/docs/installation", OciScheme)
+	}
+	return run("", "oras", args...)
+}
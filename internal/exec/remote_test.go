@@ -0,0 +1,164 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePathErrors(t *testing.T) {
+	tests := map[string]struct {
+		source config.SourcePath
+		errMsg string
+	}{
+		"unsupported scheme": {
+			source: "s3://bucket/Dredgefile",
+			errMsg: "unsupported scheme s3 in source s3://bucket/Dredgefile (expected git+<url>, https://, oci:// or file://)",
+		},
+		"http": {
+			source: "http://example.com/Dredgefile",
+			errMsg: "unsupported scheme http in source http://example.com/Dredgefile (expected git+<url>, https://, oci:// or file://)",
+		},
+		"git without path": {
+			source: "git+https://example.com/repo",
+			errMsg: "missing path in git source git+https://example.com/repo (expected <repo>[@ref]:<path>)",
+		},
+		"relative file": {
+			source: "file://Dredgefile",
+			errMsg: "invalid source file://Dredgefile (file:// needs an absolute path, use ./ for a path relative to the Dredgefile)",
+		},
+		"https repo without .git": {
+			source: "https://github.com/org/repo:path/Dredgefile",
+			errMsg: "ambiguous source https://github.com/org/repo:path/Dredgefile (use git+https://github.com/org/repo:path/Dredgefile to import a path from a git repo)",
+		},
+		"invalid checksum": {
+			source: "https://example.com/Dredgefile#md5=abc",
+			errMsg: "invalid checksum md5=abc in source https://example.com/Dredgefile#md5=abc (expected #sha256=<64 hex characters>)",
+		},
+		"oci without reference": {
+			source: "oci:////Dredgefile",
+			errMsg: "missing reference in source oci:////Dredgefile (expected oci://<registry>/<repository>[:tag|@digest][//<path>])",
+		},
+	}
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		_, err := resolvePath(test.source)
+		assert.Equal(t, test.errMsg, fmt.Sprint(err))
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dredgefile"), []byte("workflows: []\n"), 0644))
+
+	source, path, err := resolveDredgeFilePath(config.SourcePath(FileScheme + dir))
+	assert.Nil(t, err)
+	assert.Equal(t, config.SourcePath(FileScheme+dir+"/Dredgefile"), source)
+	assert.Equal(t, filepath.Join(dir, "Dredgefile"), path)
+}
+
+func TestResolveHttps(t *testing.T) {
+	content := "workflows: []\n"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/shared/Dredgefile" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+	httpClient = server.Client()
+	defer func() {
+		httpClient = http.DefaultClient
+	}()
+	inLayersDir(t, map[string]string{})
+
+	u := server.URL + "/shared/Dredgefile"
+	v1 := checksum(content)
+	path, err := resolvePath(config.SourcePath(u))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(path, LocalDredgeHttpStorage))
+	assertFileContent(t, path, content)
+	lock, err := ReadLockFile()
+	assert.Nil(t, err)
+	assert.Equal(t, LockedImport{Repo: u, Sha256: v1}, lock.Imports[u])
+
+	_, err = resolvePath(config.SourcePath(u + "#sha256=" + v1))
+	assert.Nil(t, err)
+	_, err = resolvePath(config.SourcePath(u + "#sha256=" + checksum("other")))
+	assert.Equal(t, fmt.Sprintf("checksum mismatch for %s: expected sha256 %s, got %s", u, checksum("other"), v1), fmt.Sprint(err))
+
+	content = "workflows:\n- name: hello\n"
+	v2 := checksum(content)
+	path, err = resolvePath(config.SourcePath(u))
+	assert.Nil(t, err)
+	assertFileContent(t, path, "workflows: []\n")
+
+	assert.Nil(t, os.RemoveAll(LocalDredgeHttpStorage))
+	_, err = resolvePath(config.SourcePath(u))
+	assert.Equal(t, fmt.Sprintf("checksum mismatch for %s: expected sha256 %s pinned in Dredgefile.lock, got %s, run drg update-imports", u, v1, v2), fmt.Sprint(err))
+
+	updates, err := EmptyExec("./Dredgefile", nil, nil).UpdateImports()
+	assert.Nil(t, err)
+	assert.Equal(t, []ImportUpdate{{Import: u, From: "sha256=" + v1, To: "sha256=" + v2}}, updates)
+	path, err = resolvePath(config.SourcePath(u))
+	assert.Nil(t, err)
+	assertFileContent(t, path, content)
+
+	_, err = resolvePath(config.SourcePath(server.URL + "/missing"))
+	assert.Equal(t, fmt.Sprintf("could not download %s/missing: 404 Not Found", server.URL), fmt.Sprint(err))
+}
+
+func TestResolveOci(t *testing.T) {
+	digest := "sha256:" + checksum("artifact")
+	bin := t.TempDir()
+	log := filepath.Join(bin, "oras.log")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %s
+case "$1" in
+resolve) echo %s ;;
+pull) mkdir -p "$3" && printf 'workflows:\n- name: hello\n  steps:\n  - shell:\n      cmd: echo oci\n' > "$3/Dredgefile" ;;
+esac
+`, log, digest)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(bin, "oras"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	inLayersDir(t, map[string]string{
+		"Dredgefile": `workflows:
+- name: hello
+  import:
+    source: oci://registry.example.com/dredge/shared:v1
+    workflow: hello
+`,
+	})
+
+	de, err := NewExec("./Dredgefile", "", nil, nil)
+	assert.Nil(t, err)
+	assertImportedHello(t, de, "hello", "echo oci")
+	assertImportedHello(t, de, "hello", "echo oci")
+
+	lock, err := ReadLockFile()
+	assert.Nil(t, err)
+	assert.Equal(t, LockedImport{Repo: "registry.example.com/dredge/shared", Ref: "v1", Digest: digest}, lock.Imports["oci://registry.example.com/dredge/shared:v1"])
+	assertFileContent(t, log, fmt.Sprintf("resolve registry.example.com/dredge/shared:v1\npull --output %[1]s.tmp registry.example.com/dredge/shared@%[2]s\n", filepath.Join(LocalDredgeOciStorage, hashString("registry.example.com/dredge/shared@"+digest)), digest))
+}
+
+func checksum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+func assertFileContent(t *testing.T, path, content string) {
+	actual, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, content, string(actual))
+}
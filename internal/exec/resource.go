@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/dredge-dev/dredge/internal/resource"
 )
 
@@ -29,6 +30,36 @@ func (e *DredgeExec) GetResourceDefinition(resourceName string) (*api.ResourceDe
 	return nil, fmt.Errorf("could not find resource definition for %s", resourceName)
 }
 
+// mergeResourceDefinitions adds the resource definitions from the Dredgefile
+// to rd, a definition with the same name as a default definition replaces it.
+func mergeResourceDefinitions(rd []api.ResourceDefinition, definitions []config.ResourceDefinition) []api.ResourceDefinition {
+	merged := append([]api.ResourceDefinition{}, rd...)
+	for _, definition := range definitions {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == definition.Name {
+				merged[i] = api.NewResourceDefinition(definition)
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, api.NewResourceDefinition(definition))
+		}
+	}
+	return merged
+}
+
+func (e *DredgeExec) validateResourceDefinitions() error {
+	for _, rd := range e.ResourceDefinitions {
+		for _, c := range rd.Commands {
+			if _, err := e.GetType(c.OutputType); err != nil {
+				return fmt.Errorf("resource definition %s: command %s: invalid output type %s: %v", rd.Name, c.Name, c.OutputType, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (e *DredgeExec) GetType(typeName string) (*api.Type, error) {
 	isArray := false
 	if strings.HasPrefix(typeName, "[]") {
@@ -3,12 +3,11 @@ package exec
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	osExec "os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/dredge-dev/dredge/internal/config"
@@ -19,8 +18,20 @@ const (
 	DefaultDredgeRepo      = "https://github.com/dredge-dev/dredge-repo.git"
 	LocalDredgeStorage     = ".dredge"
 	LocalDredgeRepoStorage = LocalDredgeStorage + "/repo/"
+	LocalDredgeHttpStorage = LocalDredgeStorage + "/http/"
+	LocalDredgeOciStorage  = LocalDredgeStorage + "/oci/"
+	GitScheme              = "git+"
+	HttpsScheme            = "https://"
+	OciScheme              = "oci://"
+	FileScheme             = "file://"
 )
 
+var SCHEME_RE = regexp.MustCompile(`^([a-z][a-z0-9+.-]*)://`)
+
+// GIT_HTTPS_RE matches https sources of the form <repo>.git[@ref]:<path>,
+// other https sources are downloaded.
+var GIT_HTTPS_RE = regexp.MustCompile(`\.git(@[^:/]+)?:`)
+
 func MergeSources(parent config.SourcePath, child config.SourcePath) config.SourcePath {
 	if child == "" {
 		return parent
@@ -39,42 +50,104 @@ func MergeSources(parent config.SourcePath, child config.SourcePath) config.Sour
 	return child
 }
 
+// resolvePath returns the local path of a source. A source is a path
+// relative to the Dredgefile (./), a git repo (git+<url>[@ref]:<path> or
+// <repo>[@ref]:<path>), a file to download (https://<url>[#sha256=<hex>]), an
+// OCI artifact (oci://<reference>[//<path>]) or an absolute path
+// (file:///<path>). A source without a scheme or repo is a path in the
+// default repo.
 func resolvePath(source config.SourcePath) (string, error) {
 	s := string(source)
 	if len(s) >= 2 && s[0] == '.' && os.IsPathSeparator(s[1]) {
 		return s, nil
 	}
+	switch {
+	case strings.HasPrefix(s, FileScheme):
+		return resolveFile(s)
+	case strings.HasPrefix(s, OciScheme):
+		return resolveOci(s)
+	case strings.HasPrefix(s, HttpsScheme) && !GIT_HTTPS_RE.MatchString(s):
+		return resolveHttps(s)
+	case strings.HasPrefix(s, GitScheme):
+		return resolveGit(s, s[len(GitScheme):])
+	}
+	if m := SCHEME_RE.FindStringSubmatch(s); m != nil && m[1] != "https" && m[1] != "ssh" && m[1] != "git" {
+		return "", fmt.Errorf("unsupported scheme %s in source %s (expected git+<url>, https://, oci:// or file://)", m[1], s)
+	}
 	if !strings.Contains(s, ":") {
 		s = DefaultDredgeRepo + ":" + s
 	}
+	return resolveGit(s, s)
+}
+
+func resolveGit(source, s string) (string, error) {
 	split := strings.LastIndex(s, ":")
-	return resolveRepo(s[:split], s[split+1:])
+	if split < 0 || strings.HasPrefix(s[split:], "://") {
+		return "", fmt.Errorf("missing path in git source %s (expected <repo>[@ref]:<path>)", source)
+	}
+	repo, ref := splitRef(s[:split])
+	return resolveRepo(repo, ref, s[split+1:])
 }
 
-func resolveRepo(repo, path string) (string, error) {
-	repoPath := resolveRepoPath(repo)
-	if _, err := os.Stat(LocalDredgeRepoStorage); errors.Is(err, os.ErrNotExist) {
-		err = os.MkdirAll(LocalDredgeRepoStorage, 0755)
-		if err != nil {
-			return "", err
-		}
+// splitRef splits repo@ref in the repo and the ref (a tag, branch or commit).
+// Only an @ in the last element of the repo starts a ref, so the user in
+// https://user@host/repo.git or git@host:repo.git isn't taken for a ref. An
+// empty repo is the default repo.
+func splitRef(repo string) (string, string) {
+	i := strings.LastIndex(repo, "@")
+	if i < 0 || i < strings.LastIndex(repo, "/") || strings.Contains(repo[i:], ":") {
+		return repo, ""
+	}
+	if i == 0 {
+		return DefaultDredgeRepo, repo[1:]
 	}
-	if _, err := os.Stat(repoPath); errors.Is(err, os.ErrNotExist) {
-		cmd := osExec.Command("/bin/bash", "-c", fmt.Sprintf("git clone --depth 1 %s %s", repo, repoPath))
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err = cmd.Run()
+	return repo[:i], repo[i+1:]
+}
+
+// resolveRepo returns the path in the clone of the repo at the commit that is
+// pinned for the ref in the lock file. When the ref isn't pinned yet, it is
+// resolved and the commit is added to the lock file.
+func resolveRepo(repo, ref, path string) (string, error) {
+	key := importKey(repo, ref)
+	repoPath := resolveRepoPath(key)
+	lock, err := ReadLockFile()
+	if err != nil {
+		return "", err
+	}
+	cloned, err := cloneRepo(repo, repoPath)
+	if err != nil {
+		return "", err
+	}
+	locked, ok := lock.Imports[key]
+	if !ok {
+		if !cloned {
+			if err := fetchRepo(repoPath); err != nil {
+				return "", err
+			}
+		}
+		commit, err := resolveCommit(repoPath, ref)
 		if err != nil {
+			return "", fmt.Errorf("could not resolve %s: %v", key, err)
+		}
+		locked = LockedImport{Repo: repo, Ref: ref, Commit: commit}
+		lock.Imports[key] = locked
+		if err := lock.Write(); err != nil {
 			return "", err
 		}
 	}
+	if err := checkoutPinned(repoPath, key, locked); err != nil {
+		return "", err
+	}
 	return filepath.Join(repoPath, path), nil
 }
 
 func resolveRepoPath(repo string) string {
-	hash := sha256.Sum256([]byte(repo))
-	dir := hex.EncodeToString(hash[:])
-	return LocalDredgeRepoStorage + dir
+	return LocalDredgeRepoStorage + hashString(repo)
+}
+
+func hashString(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
 }
 
 func resolveDredgeFilePath(source config.SourcePath) (config.SourcePath, string, error) {
@@ -88,7 +161,9 @@ func resolveDredgeFilePath(source config.SourcePath) (config.SourcePath, string,
 	}
 	if stat.IsDir() {
 		fullSource := string(source)
-		if !os.IsPathSeparator(path[len(path)-1]) {
+		if strings.HasPrefix(fullSource, OciScheme) && !strings.Contains(fullSource[len(OciScheme):], "//") {
+			fullSource += "//"
+		} else if !os.IsPathSeparator(path[len(path)-1]) {
 			fullSource += string(os.PathSeparator)
 		}
 		fullSource += DefaultDredgefileName
@@ -79,6 +79,7 @@ func TestResolvePath(t *testing.T) {
 
 func TestResolvePathRemote(t *testing.T) {
 	defer os.RemoveAll(".dredge")
+	defer os.Remove(LockFileName)
 
 	tests := map[string]struct {
 		source config.SourcePath
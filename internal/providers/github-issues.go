@@ -3,16 +3,22 @@ package providers
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os/exec"
-	"regexp"
 	"strings"
 
 	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
 )
 
-var URL_RE = regexp.MustCompile(`/issues/([0-9]+)`)
+// DEFAULT_GITHUB_TYPE_LABELS maps the issue types to the GitHub labels, it can
+// be changed with type_labels in the provider config.
+const DEFAULT_GITHUB_TYPE_LABELS = "bug=bug,feature=enhancement"
 
 type GithubIssuesProvider struct {
+	Client     *GithubClient
+	TypeLabels map[string]string
 }
 
 func (g *GithubIssuesProvider) Name() string {
@@ -45,69 +51,174 @@ func (g *GithubIssuesProvider) Discover(callbacks api.Callbacks) error {
 }
 
 func (g *GithubIssuesProvider) Init(config map[string]string) error {
+	typeLabels := config["type_labels"]
+	if typeLabels == "" {
+		typeLabels = DEFAULT_GITHUB_TYPE_LABELS
+	}
+	labels, err := parseTypeLabels(typeLabels)
+	if err != nil {
+		return err
+	}
+	client, err := NewGithubClient(config)
+	if err != nil {
+		return err
+	}
+	g.Client = client
+	g.TypeLabels = labels
 	return nil
 }
 
 func (g *GithubIssuesProvider) ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error) {
 	if commandName == "get" {
 		return g.Get(callbacks)
+	} else if commandName == "search" {
+		return g.Search(callbacks)
+	} else if commandName == "describe" {
+		return g.Describe(callbacks)
 	} else if commandName == "create" {
 		return g.Create(callbacks)
+	} else if commandName == "update" {
+		return g.Update(callbacks)
+	} else if commandName == "comment" {
+		return g.Comment(callbacks)
+	} else if commandName == "close" {
+		return g.Close(callbacks)
 	}
 	return nil, fmt.Errorf("could not find command %s", commandName)
 }
 
 type GithubIssue struct {
-	Author    GithubAuthor
-	Labels    []GithubLabel
-	CreatedAt string
-	Number    int
-	State     string
-	Title     string
-}
-
-type GithubAuthor struct {
-	Login string
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	State       string          `json:"state"`
+	User        GithubUser      `json:"user"`
+	Labels      []GithubLabel   `json:"labels"`
+	Assignees   []GithubUser    `json:"assignees"`
+	CreatedAt   string          `json:"created_at"`
+	HtmlUrl     string          `json:"html_url"`
+	PullRequest json.RawMessage `json:"pull_request"`
 }
 
-type GithubLabel struct {
-	Name string
+type GithubComment struct {
+	Body      string     `json:"body"`
+	User      GithubUser `json:"user"`
+	CreatedAt string     `json:"created_at"`
+	HtmlUrl   string     `json:"html_url"`
 }
 
 func (g *GithubIssuesProvider) Get(callbacks api.Callbacks) (interface{}, error) {
-	cmd := exec.Command("/bin/bash", "-c", "gh issue list --json number,title,author,state,createdAt,labels")
-	output, err := cmd.Output()
+	items, err := g.Client.GetAll(g.Client.RepoPath("/issues"), url.Values{"state": []string{"open"}})
 	if err != nil {
 		return nil, err
 	}
-	var issues []GithubIssue
-	err = json.Unmarshal(output, &issues)
+	return g.toIssues(items)
+}
+
+func (g *GithubIssuesProvider) Search(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "text",
+			Description: "Search text",
+			Type:        api.Text,
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	var out []map[string]interface{}
-	for _, issue := range issues {
-		issueType := "issue"
-		for _, label := range issue.Labels {
-			if label.Name == "bug" {
-				issueType = "bug"
-			}
-			if label.Name == "enhancement" {
-				issueType = "feature"
-			}
+	q := strings.TrimSpace(fmt.Sprintf("%s repo:%s is:issue", inputs["text"], g.Client.Repo))
+	var result struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	query := url.Values{"q": []string{q}, "per_page": []string{fmt.Sprint(REST_PAGE_SIZE)}}
+	if err := g.Client.Get("/search/issues?"+query.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return g.toIssues(result.Items)
+}
+
+func (g *GithubIssuesProvider) toIssues(items []json.RawMessage) ([]map[string]interface{}, error) {
+	out := []map[string]interface{}{}
+	for _, item := range items {
+		var issue GithubIssue
+		if err := json.Unmarshal(item, &issue); err != nil {
+			return nil, err
 		}
-		i := map[string]interface{}{
-			"name":  fmt.Sprintf("%d", issue.Number),
-			"title": issue.Title,
-			"type":  issueType,
-			"state": issue.State,
-			"date":  issue.CreatedAt,
+		if issue.PullRequest != nil {
+			continue
 		}
-		out = append(out, i)
+		out = append(out, g.toIssue(issue))
 	}
 	return out, nil
 }
 
+func (g *GithubIssuesProvider) toIssue(issue GithubIssue) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  fmt.Sprintf("%d", issue.Number),
+		"title": issue.Title,
+		"type":  g.getType(issue.Labels),
+		"state": issue.State,
+		"date":  issue.CreatedAt,
+	}
+}
+
+func (g *GithubIssuesProvider) getType(labels []GithubLabel) string {
+	var names []string
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return getIssueType(g.TypeLabels, names)
+}
+
+func (g *GithubIssuesProvider) Describe(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	var issue GithubIssue
+	err = g.Client.Get(g.issuePath(name, ""), &issue)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	items, err := g.Client.GetAll(g.issuePath(name, "/comments"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var comments []string
+	for _, item := range items {
+		var comment GithubComment
+		if err := json.Unmarshal(item, &comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, fmt.Sprintf("%s on %s:\n%s", comment.User.Login, comment.CreatedAt, comment.Body))
+	}
+
+	var labels []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.Name)
+	}
+	var assignees []string
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, assignee.Login)
+	}
+	return map[string]interface{}{
+		"name":        fmt.Sprintf("%d", issue.Number),
+		"title":       issue.Title,
+		"type":        g.getType(issue.Labels),
+		"state":       issue.State,
+		"date":        issue.CreatedAt,
+		"author":      issue.User.Login,
+		"url":         issue.HtmlUrl,
+		"description": issue.Body,
+		"labels":      strings.Join(labels, ","),
+		"assignees":   strings.Join(assignees, ","),
+		"comments":    strings.Join(comments, "\n\n"),
+	}, nil
+}
+
 func (g *GithubIssuesProvider) Create(c api.Callbacks) (interface{}, error) {
 	inputs, err := c.RequestInput([]api.InputRequest{
 		{
@@ -115,13 +226,7 @@ func (g *GithubIssuesProvider) Create(c api.Callbacks) (interface{}, error) {
 			Description: "",
 			Type:        api.Text,
 		},
-		{
-			Name:         "type",
-			Description:  "",
-			Type:         api.Select,
-			Values:       []string{"bug", "feature"},
-			DefaultValue: "bug",
-		},
+		typeInputRequest(g.TypeLabels),
 		{
 			Name:        "description",
 			Description: "",
@@ -131,27 +236,81 @@ func (g *GithubIssuesProvider) Create(c api.Callbacks) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	title := inputs["title"]
-	body := inputs["description"]
-	label := inputs["type"]
-	if label == "feature" {
-		label = "enhancement"
+	var issue GithubIssue
+	err = g.Client.Send(http.MethodPost, g.Client.RepoPath("/issues"), map[string]interface{}{
+		"title":  inputs["title"],
+		"body":   inputs["description"],
+		"labels": []string{getTypeLabel(g.TypeLabels, inputs["type"])},
+	}, &issue)
+	if err != nil {
+		return nil, err
 	}
-	cmd := exec.Command("/bin/bash", "-c", fmt.Sprintf("gh issue create --title '%s' --body '%s' --label '%s'", title, body, label))
-	output, err := cmd.Output()
+	return g.toIssue(issue), nil
+}
+
+// Update changes the state, labels and assignees of an issue, the labels and
+// assignees replace the current ones.
+func (g *GithubIssuesProvider) Update(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueUpdateInputRequests())
 	if err != nil {
 		return nil, err
 	}
-	p := URL_RE.FindStringSubmatch(string(output))
-	if len(p) < 1 {
-		return nil, fmt.Errorf("format error in gh output")
+	if err := checkIssueUpdate(inputs); err != nil {
+		return nil, err
 	}
-	name := p[1]
-	return map[string]interface{}{
-		"name":  name,
-		"title": inputs["title"],
-		"type":  inputs["type"],
-		"state": "open",
-		"date":  "now",
-	}, nil
+	body := make(map[string]interface{})
+	if inputs["state"] != "" {
+		body["state"] = inputs["state"]
+	}
+	if inputs["labels"] != "" {
+		body["labels"] = config.SplitList(inputs["labels"])
+	}
+	if inputs["assignees"] != "" {
+		body["assignees"] = config.SplitList(inputs["assignees"])
+	}
+	return g.updateIssue(inputs["name"], body)
+}
+
+func (g *GithubIssuesProvider) Comment(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueCommentInputRequests())
+	if err != nil {
+		return nil, err
+	}
+	var comment GithubComment
+	err = g.Client.Send(http.MethodPost, g.issuePath(inputs["name"], "/comments"), map[string]interface{}{
+		"body": inputs["body"],
+	}, &comment)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Added comment to issue %s: %s", inputs["name"], comment.HtmlUrl), nil
+}
+
+func (g *GithubIssuesProvider) Close(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	return g.updateIssue(name, map[string]interface{}{
+		"state": "closed",
+	})
+}
+
+func (g *GithubIssuesProvider) updateIssue(name string, body map[string]interface{}) (interface{}, error) {
+	var issue GithubIssue
+	err := g.Client.Send(http.MethodPatch, g.issuePath(name, ""), body, &issue)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g.toIssue(issue), nil
+}
+
+func (g *GithubIssuesProvider) issuePath(name, path string) string {
+	return g.Client.RepoPath("/issues/%s%s", url.PathEscape(name), path)
 }
@@ -3,18 +3,16 @@ package providers
 import (
 	"encoding/json"
 	"fmt"
-	"io"
+	"net/http"
+	"net/url"
 	"os/exec"
-	"regexp"
 	"strings"
 
-	"github.com/creack/pty"
 	"github.com/dredge-dev/dredge/internal/api"
 )
 
-var TERM_CHARS_RE = regexp.MustCompile("[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))")
-
 type GithubReleasesProvider struct {
+	Client *GithubClient
 }
 
 func (g *GithubReleasesProvider) Name() string {
@@ -47,6 +45,11 @@ func (g *GithubReleasesProvider) Discover(callbacks api.Callbacks) error {
 }
 
 func (g *GithubReleasesProvider) Init(config map[string]string) error {
+	client, err := NewGithubClient(config)
+	if err != nil {
+		return err
+	}
+	g.Client = client
 	return nil
 }
 
@@ -55,56 +58,69 @@ func (g *GithubReleasesProvider) ExecuteCommand(commandName string, c api.Callba
 		return g.Get(c)
 	} else if commandName == "describe" {
 		return g.Describe(c)
+	} else if commandName == "delete" {
+		return g.Delete(c)
 	}
 	return nil, fmt.Errorf("could not find command %s", commandName)
 }
 
+type GithubRelease struct {
+	Id          int        `json:"id"`
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Body        string     `json:"body"`
+	Author      GithubUser `json:"author"`
+	PublishedAt string     `json:"published_at"`
+	HtmlUrl     string     `json:"html_url"`
+}
+
 func (g *GithubReleasesProvider) Get(c api.Callbacks) (interface{}, error) {
-	cmd := exec.Command("/bin/bash", "-c", "gh release list")
-	f, err := pty.Start(cmd)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	bytes, err := io.ReadAll(f)
+	items, err := g.Client.GetAll(g.Client.RepoPath("/releases"), url.Values{})
 	if err != nil {
 		return nil, err
 	}
-	output := TERM_CHARS_RE.ReplaceAllString(string(bytes), "")
-	lines := strings.Split(string(output), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("no output from gh")
-	}
-	titleIndex := strings.Index(lines[0], "TITLE")
-	typeIndex := strings.Index(lines[0], "TYPE")
-	tagIndex := strings.Index(lines[0], "TAG NAME")
-	publishedIndex := strings.Index(lines[0], "PUBLISHED")
-	if titleIndex != 0 || typeIndex <= titleIndex || tagIndex <= typeIndex || publishedIndex <= tagIndex {
-		return nil, fmt.Errorf("format error in gh output")
-	}
-	var out []map[string]interface{}
-	for _, line := range lines[1:] {
-		if len(line) > publishedIndex {
-			v := map[string]interface{}{
-				"title": strings.Trim(line[titleIndex:typeIndex], " "),
-				"name":  strings.Trim(line[tagIndex:publishedIndex], " "),
-				"date":  strings.Trim(line[publishedIndex:len(line)-1], " "),
-			}
-			out = append(out, v)
+	out := []map[string]interface{}{}
+	for _, item := range items {
+		var release GithubRelease
+		if err := json.Unmarshal(item, &release); err != nil {
+			return nil, err
 		}
+		out = append(out, map[string]interface{}{
+			"title": release.Name,
+			"name":  release.TagName,
+			"date":  release.PublishedAt,
+		})
 	}
 	return out, nil
 }
 
-type GithubRelease struct {
-	Author      GithubAuthor
-	PublishedAt string
-	Url         string
-	Name        string
-	Body        string
+func (g *GithubReleasesProvider) Describe(c api.Callbacks) (interface{}, error) {
+	release, err := g.getRelease(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"name":        release.TagName,
+		"title":       release.Name,
+		"description": release.Body,
+		"url":         release.HtmlUrl,
+		"date":        release.PublishedAt,
+		"author":      release.Author.Login,
+	}, nil
 }
 
-func (g *GithubReleasesProvider) Describe(c api.Callbacks) (interface{}, error) {
+func (g *GithubReleasesProvider) Delete(c api.Callbacks) (interface{}, error) {
+	release, err := g.getRelease(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Client.Send(http.MethodDelete, g.Client.RepoPath("/releases/%d", release.Id), nil, nil); err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Deleted release %s", release.TagName), nil
+}
+
+func (g *GithubReleasesProvider) getRelease(c api.Callbacks) (*GithubRelease, error) {
 	inputs, err := c.RequestInput([]api.InputRequest{
 		{
 			Name:        "name",
@@ -112,21 +128,16 @@ func (g *GithubReleasesProvider) Describe(c api.Callbacks) (interface{}, error)
 			Type:        api.Text,
 		},
 	})
-	cmd := exec.Command("/bin/bash", "-c", fmt.Sprintf("gh release view '%s' --json name,body,author,publishedAt,url", inputs["name"]))
-	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
 	}
 	var release GithubRelease
-	err = json.Unmarshal(output, &release)
+	err = g.Client.Get(g.Client.RepoPath("/releases/tags/%s", url.PathEscape(inputs["name"])), &release)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
 	if err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"name":        release.Name,
-		"description": release.Body,
-		"url":         release.Url,
-		"date":        release.PublishedAt,
-		"author":      release.Author.Login,
-	}, nil
+	return &release, nil
 }
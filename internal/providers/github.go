@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const DEFAULT_GITHUB_URL = "https://api.github.com"
+
+// GithubClient calls the GitHub REST API for a single repository. The config
+// of the GitHub providers can contain:
+//
+//	url:    the API url, for GitHub Enterprise (default: https://api.github.com)
+//	token:  the API token (default: GITHUB_TOKEN or GH_TOKEN)
+//	repo:   the repository as <owner>/<name> (default: from the origin remote)
+type GithubClient struct {
+	RestClient
+	Repo string
+}
+
+func NewGithubClient(config map[string]string) (*GithubClient, error) {
+	u := config["url"]
+	if u == "" {
+		u = DEFAULT_GITHUB_URL
+	}
+	token := config["token"]
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	repo := config["repo"]
+	if repo == "" {
+		remote, err := getOriginRemote()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the GitHub repo, add repo to the config: %v", err)
+		}
+		repo, err = parseGithubRemote(remote)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &GithubClient{
+		RestClient: NewRestClient("GitHub", u, header),
+		Repo:       repo,
+	}, nil
+}
+
+func parseGithubRemote(remote string) (string, error) {
+	_, path, err := parseGitRemote(remote)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the GitHub repo from remote %s", remote)
+	}
+	return path, nil
+}
+
+// RepoPath returns the API path for a path in the repository.
+func (c *GithubClient) RepoPath(format string, args ...interface{}) string {
+	return "/repos/" + c.Repo + fmt.Sprintf(format, args...)
+}
+
+type GithubUser struct {
+	Login string `json:"login"`
+}
+
+type GithubLabel struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,372 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGithubClient(url string) *GithubClient {
+	c, _ := NewGithubClient(map[string]string{"url": url, "token": "secret", "repo": "dredge-dev/dredge"})
+	c.MaxRateLimit = time.Second
+	return c
+}
+
+func TestGithubClientGetAll(t *testing.T) {
+	var server *httptest.Server
+	server, requests := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/dredge-dev/dredge/releases?page=2&per_page=100>; rel="next", <%s/repos/dredge-dev/dredge/releases?page=2&per_page=100>; rel="last"`, server.URL, server.URL))
+			fmt.Fprint(w, `[{"id": 1}, {"id": 2}]`)
+		} else {
+			fmt.Fprint(w, `[{"id": 3}]`)
+		}
+	})
+	c := newTestGithubClient(server.URL)
+
+	items, err := c.GetAll(c.RepoPath("/releases"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(items))
+	assert.Equal(t, []stubRequest{
+		{Method: "GET", Path: "/repos/dredge-dev/dredge/releases?per_page=100"},
+		{Method: "GET", Path: "/repos/dredge-dev/dredge/releases?page=2&per_page=100"},
+	}, *requests)
+}
+
+func TestGithubClientErrors(t *testing.T) {
+	tests := map[string]struct {
+		handler  http.HandlerFunc
+		requests int
+		errMsg   string
+	}{
+		"retry after rate limit": {
+			handler: func() http.HandlerFunc {
+				limited := true
+				return func(w http.ResponseWriter, r *http.Request) {
+					if limited {
+						limited = false
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					fmt.Fprint(w, `{}`)
+				}
+			}(),
+			requests: 2,
+		},
+		"rate limit reset too far away": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			requests: 1,
+			errMsg:   "GitHub API rate limit exceeded, try again in 1h0m0s",
+		},
+		"rate limit after retries": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			requests: restMaxAttempts,
+			errMsg:   "GitHub API rate limit exceeded, try again in 0s",
+		},
+		"error message": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message": "Bad credentials"}`)
+			},
+			requests: 1,
+			errMsg:   "GitHub API error (401): Bad credentials",
+		},
+		"error without message": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			requests: 1,
+			errMsg:   "GitHub API error (500): Internal Server Error",
+		},
+		"forbidden": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+			},
+			requests: 1,
+			errMsg:   "GitHub API error (403): Resource not accessible by integration",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		server, requests := newStubServer(t, test.handler)
+		c := newTestGithubClient(server.URL)
+		var out map[string]interface{}
+		err := c.Get("/rate_limited", &out)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+		assert.Equal(t, test.requests, len(*requests))
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	reset := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+	tests := map[string]struct {
+		status  int
+		header  map[string]string
+		wait    time.Duration
+		limited bool
+	}{
+		"ok":           {status: http.StatusOK},
+		"forbidden":    {status: http.StatusForbidden},
+		"too many":     {status: http.StatusTooManyRequests, limited: true},
+		"retry after":  {status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "5"}, wait: 5 * time.Second, limited: true},
+		"github reset": {status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, wait: time.Hour, limited: true},
+		"gitlab reset": {status: http.StatusTooManyRequests, header: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": reset}, wait: time.Hour, limited: true},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		resp := &http.Response{StatusCode: test.status, Header: http.Header{}}
+		for name, value := range test.header {
+			resp.Header.Set(name, value)
+		}
+		wait, limited := rateLimitWait(resp)
+		assert.Equal(t, test.limited, limited)
+		assert.InDelta(t, test.wait.Seconds(), wait.Seconds(), 2)
+	}
+}
+
+func TestParseGithubRemote(t *testing.T) {
+	tests := map[string]struct {
+		remote string
+		repo   string
+		errMsg string
+	}{
+		"ssh":               {remote: "git@github.com:dredge-dev/dredge.git", repo: "dredge-dev/dredge"},
+		"https":             {remote: "https://github.com/dredge-dev/dredge.git", repo: "dredge-dev/dredge"},
+		"https without git": {remote: "https://github.com/dredge-dev/dredge", repo: "dredge-dev/dredge"},
+		"enterprise":        {remote: "https://github.example.com/team/app/", repo: "team/app"},
+		"invalid":           {remote: "dredge", errMsg: "could not determine the GitHub repo from remote dredge"},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		repo, err := parseGithubRemote(test.remote)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.repo, repo)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestNewGithubClient(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-token")
+
+	c, err := NewGithubClient(map[string]string{"url": "https://github.example.com/api/v3/", "repo": "team/app"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3", c.Url)
+	assert.Equal(t, "Bearer gh-token", c.Header.Get("Authorization"))
+	assert.Equal(t, "team/app", c.Repo)
+
+	c, err = NewGithubClient(map[string]string{"token": "config-token", "repo": "team/app"})
+	assert.Nil(t, err)
+	assert.Equal(t, DEFAULT_GITHUB_URL, c.Url)
+	assert.Equal(t, "Bearer config-token", c.Header.Get("Authorization"))
+}
+
+func TestGithubReleasesProvider(t *testing.T) {
+	server, requests := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/dredge-dev/dredge/releases":
+			fmt.Fprint(w, `[{"id": 1, "tag_name": "v1.0.0", "name": "First release", "published_at": "2022-01-01T10:00:00Z"}]`)
+		case "GET /repos/dredge-dev/dredge/releases/tags/v1.0.0":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0.0", "name": "First release", "body": "Notes", "html_url": "https://github.com/dredge-dev/dredge/releases/v1.0.0", "published_at": "2022-01-01T10:00:00Z", "author": {"login": "dev"}}`)
+		case "DELETE /repos/dredge-dev/dredge/releases/1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	})
+	p := &GithubReleasesProvider{}
+	err := p.Init(map[string]string{"url": server.URL, "repo": "dredge-dev/dredge", "token": "secret"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("get", &callbacksMock{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "v1.0.0", "title": "First release", "date": "2022-01-01T10:00:00Z"},
+	}, output)
+
+	c := &callbacksMock{values: map[string]string{"name": "v1.0.0"}}
+	output, err = p.ExecuteCommand("describe", c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "v1.0.0",
+		"title":       "First release",
+		"description": "Notes",
+		"url":         "https://github.com/dredge-dev/dredge/releases/v1.0.0",
+		"date":        "2022-01-01T10:00:00Z",
+		"author":      "dev",
+	}, output)
+
+	output, err = p.ExecuteCommand("delete", c)
+	assert.Nil(t, err)
+	assert.Equal(t, "Deleted release v1.0.0", output)
+	assert.Equal(t, stubRequest{Method: "DELETE", Path: "/repos/dredge-dev/dredge/releases/1"}, (*requests)[len(*requests)-1])
+
+	_, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "v9"}})
+	assert.Equal(t, &api.NoResult{}, err)
+}
+
+func TestGithubIssuesProvider(t *testing.T) {
+	server, requests := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/dredge-dev/dredge/issues":
+			fmt.Fprint(w, `[
+				{"number": 1, "title": "Crash", "state": "open", "created_at": "2022-01-01T10:00:00Z", "labels": [{"name": "bug"}]},
+				{"number": 2, "title": "Add feature", "state": "open", "created_at": "2022-01-02T10:00:00Z", "pull_request": {}}
+			]`)
+		case "POST /repos/dredge-dev/dredge/issues":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number": 3, "title": "Don't crash", "state": "open", "created_at": "2022-01-03T10:00:00Z", "labels": [{"name": "enhancement"}]}`)
+		case "PATCH /repos/dredge-dev/dredge/issues/1":
+			fmt.Fprint(w, `{"number": 1, "title": "Crash", "state": "closed", "created_at": "2022-01-01T10:00:00Z", "labels": [{"name": "bug"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	})
+	p := &GithubIssuesProvider{}
+	err := p.Init(map[string]string{"url": server.URL, "repo": "dredge-dev/dredge"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("get", &callbacksMock{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "1", "title": "Crash", "type": "bug", "state": "open", "date": "2022-01-01T10:00:00Z"},
+	}, output)
+	assert.Equal(t, "/repos/dredge-dev/dredge/issues?per_page=100&state=open", (*requests)[0].Path)
+
+	output, err = p.ExecuteCommand("create", &callbacksMock{values: map[string]string{"title": "Don't crash", "type": "feature", "description": "It's broken"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "3", "title": "Don't crash", "type": "feature", "state": "open", "date": "2022-01-03T10:00:00Z"}, output)
+	assert.Equal(t, map[string]interface{}{
+		"title":  "Don't crash",
+		"body":   "It's broken",
+		"labels": []interface{}{"enhancement"},
+	}, (*requests)[1].Body)
+
+	output, err = p.ExecuteCommand("close", &callbacksMock{values: map[string]string{"name": "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "closed", output.(map[string]interface{})["state"])
+	assert.Equal(t, map[string]interface{}{"state": "closed"}, (*requests)[2].Body)
+
+	_, err = p.ExecuteCommand("close", &callbacksMock{values: map[string]string{"name": "42"}})
+	assert.Equal(t, &api.NoResult{}, err)
+}
+
+func TestGithubIssuesLifecycle(t *testing.T) {
+	server, requests := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /search/issues":
+			fmt.Fprint(w, `{"total_count": 2, "items": [
+				{"number": 1, "title": "Login crash", "state": "open", "created_at": "2022-01-01T10:00:00Z", "labels": [{"name": "type: bug"}]},
+				{"number": 2, "title": "Fix login", "state": "open", "created_at": "2022-01-02T10:00:00Z", "pull_request": {}}
+			]}`)
+		case "GET /repos/dredge-dev/dredge/issues/1":
+			fmt.Fprint(w, `{"number": 1, "title": "Login crash", "body": "It crashes", "state": "open", "created_at": "2022-01-01T10:00:00Z", "html_url": "https://github.com/dredge-dev/dredge/issues/1", "user": {"login": "dev"}, "labels": [{"name": "type: bug"}, {"name": "urgent"}], "assignees": [{"login": "alice"}, {"login": "bob"}]}`)
+		case "GET /repos/dredge-dev/dredge/issues/1/comments":
+			fmt.Fprint(w, `[{"body": "Confirmed", "user": {"login": "alice"}, "created_at": "2022-01-02T10:00:00Z"}]`)
+		case "PATCH /repos/dredge-dev/dredge/issues/1":
+			fmt.Fprint(w, `{"number": 1, "title": "Login crash", "state": "closed", "created_at": "2022-01-01T10:00:00Z", "labels": [{"name": "type: feature"}]}`)
+		case "POST /repos/dredge-dev/dredge/issues/1/comments":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"body": "Fixed", "html_url": "https://github.com/dredge-dev/dredge/issues/1#issuecomment-1"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	})
+	p := &GithubIssuesProvider{}
+	err := p.Init(map[string]string{"url": server.URL, "repo": "dredge-dev/dredge", "type_labels": "bug=type: bug, feature=type: feature"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("search", &callbacksMock{values: map[string]string{"text": "login"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "1", "title": "Login crash", "type": "bug", "state": "open", "date": "2022-01-01T10:00:00Z"},
+	}, output)
+	assert.Equal(t, "/search/issues?per_page=100&q=login+repo%3Adredge-dev%2Fdredge+is%3Aissue", (*requests)[0].Path)
+
+	output, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "1",
+		"title":       "Login crash",
+		"type":        "bug",
+		"state":       "open",
+		"date":        "2022-01-01T10:00:00Z",
+		"author":      "dev",
+		"url":         "https://github.com/dredge-dev/dredge/issues/1",
+		"description": "It crashes",
+		"labels":      "type: bug,urgent",
+		"assignees":   "alice,bob",
+		"comments":    "alice on 2022-01-02T10:00:00Z:\nConfirmed",
+	}, output)
+
+	output, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "1", "state": "closed", "labels": "type: feature", "assignees": "alice, bob"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "1", "title": "Login crash", "type": "feature", "state": "closed", "date": "2022-01-01T10:00:00Z"}, output)
+	assert.Equal(t, map[string]interface{}{
+		"state":     "closed",
+		"labels":    []interface{}{"type: feature"},
+		"assignees": []interface{}{"alice", "bob"},
+	}, (*requests)[len(*requests)-1].Body)
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "1"}})
+	assert.Equal(t, "nothing to update, provide a state, labels or assignees", fmt.Sprint(err))
+
+	output, err = p.ExecuteCommand("comment", &callbacksMock{values: map[string]string{"name": "1", "body": "Fixed"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "Added comment to issue 1: https://github.com/dredge-dev/dredge/issues/1#issuecomment-1", output)
+	assert.Equal(t, map[string]interface{}{"body": "Fixed"}, (*requests)[len(*requests)-1].Body)
+
+	_, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "42"}})
+	assert.Equal(t, &api.NoResult{}, err)
+}
+
+func TestParseTypeLabels(t *testing.T) {
+	labels, err := parseTypeLabels(DEFAULT_GITHUB_TYPE_LABELS)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"bug": "bug", "feature": "enhancement"}, labels)
+
+	_, err = parseTypeLabels("bug")
+	assert.Equal(t, "invalid type label bug (expected <type>=<label>)", fmt.Sprint(err))
+}
+
+func TestIssueTypes(t *testing.T) {
+	labels := map[string]string{"task": "chore", "feature": "enhancement", "bug": "bug", "defect": "bug"}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "bug", getIssueType(labels, []string{"bug"}))
+	}
+	assert.Equal(t, "task", getIssueType(labels, []string{"bug", "chore"}))
+	assert.Equal(t, "issue", getIssueType(labels, []string{"urgent"}))
+
+	request := typeInputRequest(labels)
+	assert.Equal(t, []string{"bug", "defect", "feature", "task"}, request.Values)
+	assert.Equal(t, "bug", request.DefaultValue)
+	assert.Equal(t, "chore", typeInputRequest(map[string]string{"docs": "documentation", "chore": "chore"}).DefaultValue)
+}
@@ -0,0 +1,302 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+// DEFAULT_GITLAB_TYPE_LABELS maps the issue types to the GitLab labels, it can
+// be changed with type_labels in the provider config.
+const DEFAULT_GITLAB_TYPE_LABELS = "bug=bug,feature=feature"
+
+type GitlabIssuesProvider struct {
+	Client     *GitlabClient
+	TypeLabels map[string]string
+}
+
+func (g *GitlabIssuesProvider) Name() string {
+	return "gitlab-issues"
+}
+
+func (g *GitlabIssuesProvider) Discover(callbacks api.Callbacks) error {
+	return discoverGitlab(callbacks, "issue", "gitlab-issues", "issues")
+}
+
+func (g *GitlabIssuesProvider) Init(config map[string]string) error {
+	typeLabels := config["type_labels"]
+	if typeLabels == "" {
+		typeLabels = DEFAULT_GITLAB_TYPE_LABELS
+	}
+	labels, err := parseTypeLabels(typeLabels)
+	if err != nil {
+		return err
+	}
+	client, err := NewGitlabClient(config)
+	if err != nil {
+		return err
+	}
+	g.Client = client
+	g.TypeLabels = labels
+	return nil
+}
+
+func (g *GitlabIssuesProvider) ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error) {
+	if commandName == "get" {
+		return g.Get(callbacks)
+	} else if commandName == "search" {
+		return g.Search(callbacks)
+	} else if commandName == "describe" {
+		return g.Describe(callbacks)
+	} else if commandName == "create" {
+		return g.Create(callbacks)
+	} else if commandName == "update" {
+		return g.Update(callbacks)
+	} else if commandName == "comment" {
+		return g.Comment(callbacks)
+	} else if commandName == "close" {
+		return g.Close(callbacks)
+	}
+	return nil, fmt.Errorf("could not find command %s", commandName)
+}
+
+type GitlabIssue struct {
+	Iid         int          `json:"iid"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	State       string       `json:"state"`
+	Author      GitlabUser   `json:"author"`
+	Labels      []string     `json:"labels"`
+	Assignees   []GitlabUser `json:"assignees"`
+	CreatedAt   string       `json:"created_at"`
+	WebUrl      string       `json:"web_url"`
+}
+
+type GitlabNote struct {
+	Body      string     `json:"body"`
+	Author    GitlabUser `json:"author"`
+	CreatedAt string     `json:"created_at"`
+	System    bool       `json:"system"`
+}
+
+func (g *GitlabIssuesProvider) Get(callbacks api.Callbacks) (interface{}, error) {
+	return g.getIssues(url.Values{"state": []string{"opened"}})
+}
+
+func (g *GitlabIssuesProvider) Search(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "text",
+			Description: "Search text",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g.getIssues(url.Values{"search": []string{inputs["text"]}})
+}
+
+func (g *GitlabIssuesProvider) getIssues(query url.Values) (interface{}, error) {
+	items, err := g.Client.GetAll(g.Client.ProjectPath("/issues"), query)
+	if err != nil {
+		return nil, err
+	}
+	out := []map[string]interface{}{}
+	for _, item := range items {
+		var issue GitlabIssue
+		if err := json.Unmarshal(item, &issue); err != nil {
+			return nil, err
+		}
+		out = append(out, g.toIssue(issue))
+	}
+	return out, nil
+}
+
+func (g *GitlabIssuesProvider) toIssue(issue GitlabIssue) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  fmt.Sprintf("%d", issue.Iid),
+		"title": issue.Title,
+		"type":  getIssueType(g.TypeLabels, issue.Labels),
+		"state": gitlabState(issue.State),
+		"date":  issue.CreatedAt,
+	}
+}
+
+// gitlabState returns the state of an issue or merge request with the same
+// names as the other providers.
+func gitlabState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return state
+}
+
+func (g *GitlabIssuesProvider) Describe(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	var issue GitlabIssue
+	err = g.Client.Get(g.issuePath(name, ""), &issue)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	items, err := g.Client.GetAll(g.issuePath(name, "/notes"), url.Values{"sort": []string{"asc"}})
+	if err != nil {
+		return nil, err
+	}
+	var comments []string
+	for _, item := range items {
+		var note GitlabNote
+		if err := json.Unmarshal(item, &note); err != nil {
+			return nil, err
+		}
+		if note.System {
+			continue
+		}
+		comments = append(comments, fmt.Sprintf("%s on %s:\n%s", note.Author.Username, note.CreatedAt, note.Body))
+	}
+
+	var assignees []string
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, assignee.Username)
+	}
+	return map[string]interface{}{
+		"name":        fmt.Sprintf("%d", issue.Iid),
+		"title":       issue.Title,
+		"type":        getIssueType(g.TypeLabels, issue.Labels),
+		"state":       gitlabState(issue.State),
+		"date":        issue.CreatedAt,
+		"author":      issue.Author.Username,
+		"url":         issue.WebUrl,
+		"description": issue.Description,
+		"labels":      strings.Join(issue.Labels, ","),
+		"assignees":   strings.Join(assignees, ","),
+		"comments":    strings.Join(comments, "\n\n"),
+	}, nil
+}
+
+func (g *GitlabIssuesProvider) Create(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "title",
+			Description: "",
+			Type:        api.Text,
+		},
+		typeInputRequest(g.TypeLabels),
+		{
+			Name:        "description",
+			Description: "",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var issue GitlabIssue
+	err = g.Client.Send(http.MethodPost, g.Client.ProjectPath("/issues"), map[string]interface{}{
+		"title":       inputs["title"],
+		"description": inputs["description"],
+		"labels":      getTypeLabel(g.TypeLabels, inputs["type"]),
+	}, &issue)
+	if err != nil {
+		return nil, err
+	}
+	return g.toIssue(issue), nil
+}
+
+// Update reopens or closes the issue and replaces its labels and assignees,
+// the assignees are looked up by their GitLab username.
+func (g *GitlabIssuesProvider) Update(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueUpdateInputRequests())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIssueUpdate(inputs); err != nil {
+		return nil, err
+	}
+	body := make(map[string]interface{})
+	if inputs["state"] == "open" {
+		body["state_event"] = "reopen"
+	} else if inputs["state"] == "closed" {
+		body["state_event"] = "close"
+	}
+	if inputs["labels"] != "" {
+		body["labels"] = strings.Join(config.SplitList(inputs["labels"]), ",")
+	}
+	if inputs["assignees"] != "" {
+		ids, err := g.getUserIds(config.SplitList(inputs["assignees"]))
+		if err != nil {
+			return nil, err
+		}
+		body["assignee_ids"] = ids
+	}
+	return g.updateIssue(inputs["name"], body)
+}
+
+func (g *GitlabIssuesProvider) getUserIds(usernames []string) ([]int, error) {
+	var ids []int
+	for _, username := range usernames {
+		var users []GitlabUser
+		if err := g.Client.Get("/users?"+url.Values{"username": []string{username}}.Encode(), &users); err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("could not find GitLab user %s", username)
+		}
+		ids = append(ids, users[0].Id)
+	}
+	return ids, nil
+}
+
+func (g *GitlabIssuesProvider) Comment(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueCommentInputRequests())
+	if err != nil {
+		return nil, err
+	}
+	err = g.Client.Send(http.MethodPost, g.issuePath(inputs["name"], "/notes"), map[string]interface{}{
+		"body": inputs["body"],
+	}, nil)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Added comment to issue %s", inputs["name"]), nil
+}
+
+func (g *GitlabIssuesProvider) Close(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	return g.updateIssue(name, map[string]interface{}{
+		"state_event": "close",
+	})
+}
+
+func (g *GitlabIssuesProvider) updateIssue(name string, body map[string]interface{}) (interface{}, error) {
+	var issue GitlabIssue
+	err := g.Client.Send(http.MethodPut, g.issuePath(name, ""), body, &issue)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g.toIssue(issue), nil
+}
+
+func (g *GitlabIssuesProvider) issuePath(name, path string) string {
+	return g.Client.ProjectPath("/issues/%s%s", url.PathEscape(name), path)
+}
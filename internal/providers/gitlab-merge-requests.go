@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dredge-dev/dredge/internal/api"
+)
+
+type GitlabMergeRequestsProvider struct {
+	Client *GitlabClient
+}
+
+func (g *GitlabMergeRequestsProvider) Name() string {
+	return "gitlab-merge-requests"
+}
+
+func (g *GitlabMergeRequestsProvider) Discover(callbacks api.Callbacks) error {
+	return discoverGitlab(callbacks, "merge-request", "gitlab-merge-requests", "merge requests")
+}
+
+func (g *GitlabMergeRequestsProvider) Init(config map[string]string) error {
+	client, err := NewGitlabClient(config)
+	if err != nil {
+		return err
+	}
+	g.Client = client
+	return nil
+}
+
+func (g *GitlabMergeRequestsProvider) ExecuteCommand(commandName string, c api.Callbacks) (interface{}, error) {
+	if commandName == "get" {
+		return g.Get(c)
+	} else if commandName == "describe" {
+		return g.Describe(c)
+	} else if commandName == "create" {
+		return g.Create(c)
+	}
+	return nil, fmt.Errorf("could not find command %s", commandName)
+}
+
+type GitlabMergeRequest struct {
+	Iid          int        `json:"iid"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"`
+	Author       GitlabUser `json:"author"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	CreatedAt    string     `json:"created_at"`
+	WebUrl       string     `json:"web_url"`
+}
+
+func (g *GitlabMergeRequestsProvider) Get(c api.Callbacks) (interface{}, error) {
+	items, err := g.Client.GetAll(g.Client.ProjectPath("/merge_requests"), url.Values{"state": []string{"opened"}})
+	if err != nil {
+		return nil, err
+	}
+	out := []map[string]interface{}{}
+	for _, item := range items {
+		var mr GitlabMergeRequest
+		if err := json.Unmarshal(item, &mr); err != nil {
+			return nil, err
+		}
+		out = append(out, toMergeRequest(mr))
+	}
+	return out, nil
+}
+
+func toMergeRequest(mr GitlabMergeRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   fmt.Sprintf("%d", mr.Iid),
+		"title":  mr.Title,
+		"state":  gitlabState(mr.State),
+		"source": mr.SourceBranch,
+		"target": mr.TargetBranch,
+		"author": mr.Author.Username,
+		"date":   mr.CreatedAt,
+	}
+}
+
+func (g *GitlabMergeRequestsProvider) Describe(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "name",
+			Description: "Merge request number",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var mr GitlabMergeRequest
+	err = g.Client.Get(g.Client.ProjectPath("/merge_requests/%s", url.PathEscape(inputs["name"])), &mr)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := toMergeRequest(mr)
+	result["description"] = mr.Description
+	result["url"] = mr.WebUrl
+	return result, nil
+}
+
+// Create opens a merge request, the target branch defaults to the default
+// branch of the project.
+func (g *GitlabMergeRequestsProvider) Create(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "title",
+			Description: "Title",
+			Type:        api.Text,
+		},
+		{
+			Name:        "source",
+			Description: "Source branch",
+			Type:        api.Text,
+		},
+		{
+			Name:        "target",
+			Description: "Target branch",
+			Type:        api.Text,
+		},
+		{
+			Name:        "description",
+			Description: "Description",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	target := inputs["target"]
+	if target == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := g.Client.Get(g.Client.ProjectPath(""), &project); err != nil {
+			return nil, err
+		}
+		target = project.DefaultBranch
+	}
+	var mr GitlabMergeRequest
+	err = g.Client.Send(http.MethodPost, g.Client.ProjectPath("/merge_requests"), map[string]interface{}{
+		"title":         inputs["title"],
+		"source_branch": inputs["source"],
+		"target_branch": target,
+		"description":   inputs["description"],
+	}, &mr)
+	if err != nil {
+		return nil, err
+	}
+	return toMergeRequest(mr), nil
+}
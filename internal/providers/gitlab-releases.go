@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dredge-dev/dredge/internal/api"
+)
+
+type GitlabReleasesProvider struct {
+	Client *GitlabClient
+}
+
+func (g *GitlabReleasesProvider) Name() string {
+	return "gitlab-releases"
+}
+
+func (g *GitlabReleasesProvider) Discover(callbacks api.Callbacks) error {
+	return discoverGitlab(callbacks, "release", "gitlab-releases", "releases")
+}
+
+func (g *GitlabReleasesProvider) Init(config map[string]string) error {
+	client, err := NewGitlabClient(config)
+	if err != nil {
+		return err
+	}
+	g.Client = client
+	return nil
+}
+
+func (g *GitlabReleasesProvider) ExecuteCommand(commandName string, c api.Callbacks) (interface{}, error) {
+	if commandName == "get" {
+		return g.Get(c)
+	} else if commandName == "describe" {
+		return g.Describe(c)
+	} else if commandName == "delete" {
+		return g.Delete(c)
+	}
+	return nil, fmt.Errorf("could not find command %s", commandName)
+}
+
+type GitlabRelease struct {
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Author      GitlabUser `json:"author"`
+	ReleasedAt  string     `json:"released_at"`
+	Links       struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+func (g *GitlabReleasesProvider) Get(c api.Callbacks) (interface{}, error) {
+	items, err := g.Client.GetAll(g.Client.ProjectPath("/releases"), nil)
+	if err != nil {
+		return nil, err
+	}
+	out := []map[string]interface{}{}
+	for _, item := range items {
+		var release GitlabRelease
+		if err := json.Unmarshal(item, &release); err != nil {
+			return nil, err
+		}
+		out = append(out, map[string]interface{}{
+			"title": release.Name,
+			"name":  release.TagName,
+			"date":  release.ReleasedAt,
+		})
+	}
+	return out, nil
+}
+
+func (g *GitlabReleasesProvider) Describe(c api.Callbacks) (interface{}, error) {
+	release, err := g.getRelease(c)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"name":        release.TagName,
+		"title":       release.Name,
+		"description": release.Description,
+		"url":         release.Links.Self,
+		"date":        release.ReleasedAt,
+		"author":      release.Author.Username,
+	}, nil
+}
+
+func (g *GitlabReleasesProvider) Delete(c api.Callbacks) (interface{}, error) {
+	release, err := g.getRelease(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Client.Send(http.MethodDelete, g.Client.ProjectPath("/releases/%s", url.PathEscape(release.TagName)), nil, nil); err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Deleted release %s", release.TagName), nil
+}
+
+func (g *GitlabReleasesProvider) getRelease(c api.Callbacks) (*GitlabRelease, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "name",
+			Description: "Name",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var release GitlabRelease
+	err = g.Client.Get(g.Client.ProjectPath("/releases/%s", url.PathEscape(inputs["name"])), &release)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
@@ -0,0 +1,120 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const (
+	DEFAULT_GITLAB_URL = "https://gitlab.com"
+	GITLAB_HOST        = "gitlab.com"
+	// GITLAB_HOSTS_VARIABLE is the Dredgefile variable with the hostnames of
+	// self-hosted GitLab instances, used to discover the GitLab providers.
+	GITLAB_HOSTS_VARIABLE = "gitlab_hosts"
+	// GITLAB_HOSTS_ENV does the same as GITLAB_HOSTS_VARIABLE for all projects.
+	GITLAB_HOSTS_ENV = "DRG_GITLAB_HOSTS"
+)
+
+// GitlabClient calls the GitLab REST API for a single project. The config of
+// the GitLab providers can contain:
+//
+//	url:      the GitLab url, for self-hosted instances (default: https://gitlab.com)
+//	token:    the API token (default: GITLAB_TOKEN)
+//	project:  the project path as <group>/<name> (default: from the origin remote)
+type GitlabClient struct {
+	RestClient
+	Project string
+}
+
+func NewGitlabClient(config map[string]string) (*GitlabClient, error) {
+	u := strings.TrimSuffix(config["url"], "/")
+	if u == "" {
+		u = DEFAULT_GITLAB_URL
+	}
+	token := config["token"]
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	header := http.Header{}
+	if token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+
+	project := config["project"]
+	if project == "" {
+		remote, err := getOriginRemote()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the GitLab project, add project to the config: %v", err)
+		}
+		_, project, err = parseGitRemote(remote)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the GitLab project from remote %s", remote)
+		}
+	}
+	return &GitlabClient{
+		RestClient: NewRestClient("GitLab", u+"/api/v4", header),
+		Project:    project,
+	}, nil
+}
+
+// ProjectPath returns the API path for a path in the project.
+func (c *GitlabClient) ProjectPath(format string, args ...interface{}) string {
+	return "/projects/" + url.PathEscape(c.Project) + fmt.Sprintf(format, args...)
+}
+
+type GitlabUser struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// discoverGitlab adds the provider for the resource when the origin remote is
+// on gitlab.com or on one of the configured GitLab hosts.
+func discoverGitlab(callbacks api.Callbacks, resource, provider, description string) error {
+	remote, err := getOriginRemote()
+	if err != nil {
+		return err
+	}
+	host, _, err := parseGitRemote(remote)
+	if err != nil {
+		return err
+	}
+	if !isGitlabHost(callbacks, host) {
+		return nil
+	}
+	confirmed, err := callbacks.Confirm("GitLab repo detected, do you use GitLab %s?", description)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+	err = callbacks.Log(api.Info, "Adding %s as a provider", provider)
+	if err != nil {
+		return err
+	}
+	var providerConfig map[string]string
+	if host != GITLAB_HOST {
+		providerConfig = map[string]string{"url": "https://" + host}
+	}
+	return callbacks.AddProviderToDredgefile(resource, provider, providerConfig)
+}
+
+func isGitlabHost(callbacks api.Callbacks, host string) bool {
+	hosts := []string{GITLAB_HOST}
+	hosts = append(hosts, config.SplitList(os.Getenv(GITLAB_HOSTS_ENV))...)
+	if value, ok := callbacks.GetEnv(GITLAB_HOSTS_VARIABLE); ok {
+		hosts = append(hosts, config.SplitList(fmt.Sprint(value))...)
+	}
+	for _, h := range hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package providers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeHelperScript writes an executable with the given name that logs its
+// arguments and runs the helper test of the test binary. It returns the path
+// of the executable and of the log.
+func writeHelperScript(t *testing.T, name, helperTest string) (string, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	log := filepath.Join(dir, "args.log")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$*\" >> %s\nGO_WANT_HELPER_PROCESS=1 exec %s -test.run=%s -- \"$@\"\n", log, os.Args[0], helperTest)
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, log
+}
+
+// readHelperLog returns the logged arguments of the calls to a helper script
+// and clears the log.
+func readHelperLog(t *testing.T, log string) []string {
+	b, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(log)
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+// helperArgs returns the arguments passed to the helper script, ok is false
+// when the test doesn't run as a helper process.
+func helperArgs() ([]string, bool) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return nil, false
+	}
+	for i, arg := range os.Args {
+		if arg == "--" {
+			return os.Args[i+1:], true
+		}
+	}
+	return nil, true
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const ISSUE_STATE_PATTERN = "^(open|closed)$"
+
+// parseTypeLabels parses a comma separated list of <type>=<label> pairs.
+func parseTypeLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, item := range config.SplitList(value) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid type label %s (expected <type>=<label>)", item)
+		}
+		labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return labels, nil
+}
+
+// getIssueTypes returns the sorted types of the type labels.
+func getIssueTypes(typeLabels map[string]string) []string {
+	var types []string
+	for t := range typeLabels {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// getIssueType returns the type of the last label that maps to a type, or
+// issue when none of the labels map to a type. When types share a label, the
+// first type in alphabetical order is returned.
+func getIssueType(typeLabels map[string]string, labels []string) string {
+	issueType := "issue"
+	types := getIssueTypes(typeLabels)
+	for _, label := range labels {
+		for _, t := range types {
+			if label == typeLabels[t] {
+				issueType = t
+				break
+			}
+		}
+	}
+	return issueType
+}
+
+// typeInputRequest requests the type of a new issue, from the configured
+// types. The default is bug, or the first type when there is no bug type.
+func typeInputRequest(typeLabels map[string]string) api.InputRequest {
+	types := getIssueTypes(typeLabels)
+	defaultValue := "bug"
+	if _, ok := typeLabels[defaultValue]; !ok && len(types) > 0 {
+		defaultValue = types[0]
+	}
+	return api.InputRequest{
+		Name:         "type",
+		Description:  "",
+		Type:         api.Select,
+		Values:       types,
+		DefaultValue: defaultValue,
+	}
+}
+
+func getTypeLabel(typeLabels map[string]string, issueType string) string {
+	if label, ok := typeLabels[issueType]; ok {
+		return label
+	}
+	return issueType
+}
+
+var issueNameInputRequest = api.InputRequest{
+	Name:        "name",
+	Description: "Issue number",
+	Type:        api.Text,
+}
+
+func requestIssueName(c api.Callbacks) (string, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{issueNameInputRequest})
+	if err != nil {
+		return "", err
+	}
+	return inputs["name"], nil
+}
+
+// issueUpdateInputRequests requests the issue and its new state, labels and
+// assignees. The labels and assignees are comma separated, inputs that are
+// left empty are not changed.
+func issueUpdateInputRequests() []api.InputRequest {
+	return []api.InputRequest{
+		issueNameInputRequest,
+		{
+			Name:        "state",
+			Description: "Issue state (open or closed)",
+			Type:        api.Text,
+			Pattern:     ISSUE_STATE_PATTERN,
+		},
+		{
+			Name:        "labels",
+			Description: "Comma separated labels",
+			Type:        api.Text,
+		},
+		{
+			Name:        "assignees",
+			Description: "Comma separated assignees",
+			Type:        api.Text,
+		},
+	}
+}
+
+// checkIssueUpdate returns an error when none of the update inputs are set.
+func checkIssueUpdate(inputs map[string]string) error {
+	if inputs["state"] == "" && inputs["labels"] == "" && inputs["assignees"] == "" {
+		return fmt.Errorf("nothing to update, provide a state, labels or assignees")
+	}
+	return nil
+}
+
+func issueCommentInputRequests() []api.InputRequest {
+	return []api.InputRequest{
+		issueNameInputRequest,
+		{
+			Name:        "body",
+			Description: "Comment",
+			Type:        api.Text,
+			Required:    true,
+		},
+	}
+}
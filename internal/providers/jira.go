@@ -0,0 +1,484 @@
+package providers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const (
+	// DEFAULT_JIRA_TYPES maps the issue types to the Jira issue types, it can
+	// be changed with types in the provider config.
+	DEFAULT_JIRA_TYPES = "bug=Bug,feature=Story"
+	DEFAULT_JIRA_JQL   = `project = "{{ .project }}" AND statusCategory != Done ORDER BY created DESC`
+	JIRA_DONE          = "done"
+	JIRA_NEW           = "new"
+	JIRA_IN_PROGRESS   = "indeterminate"
+	JIRA_API_PATH      = "/rest/api/2"
+	JIRA_ISSUE_FIELDS  = "summary,issuetype,status,created"
+)
+
+var JIRA_PROJECT_KEY_RE = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// JiraProvider implements the issue resource with the Jira REST API. The
+// config contains:
+//
+//	url:      the Jira url
+//	project:  the project key
+//	user:     the user for basic authentication with an API token (Jira Cloud)
+//	token:    the API token, or a personal access token when there is no user
+//	          (default: JIRA_API_TOKEN)
+//	jql:      the JQL filter for get, templated with the project key
+//	types:    comma separated <type>=<Jira issue type> pairs
+//
+// The state is closed for Jira statuses in the done category and open for all
+// other statuses.
+type JiraProvider struct {
+	Client  RestClient
+	Url     string
+	Project string
+	Jql     string
+	Types   map[string]string
+}
+
+func (j *JiraProvider) Name() string {
+	return "jira"
+}
+
+func (j *JiraProvider) Discover(callbacks api.Callbacks) error {
+	return nil
+}
+
+func (j *JiraProvider) Init(conf map[string]string) error {
+	err := checkConfig(conf, []string{"url", "project"})
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(conf["url"])
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %s in config (expected http:// or https://)", conf["url"])
+	}
+	if !JIRA_PROJECT_KEY_RE.MatchString(conf["project"]) {
+		return fmt.Errorf("invalid project key %s in config (expected uppercase letters and digits)", conf["project"])
+	}
+	token := conf["token"]
+	if token == "" {
+		token = os.Getenv("JIRA_API_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("could not find field token in config or JIRA_API_TOKEN")
+	}
+	types := conf["types"]
+	if types == "" {
+		types = DEFAULT_JIRA_TYPES
+	}
+	j.Types, err = parseTypeLabels(types)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if conf["user"] != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(conf["user"]+":"+token)))
+	} else {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	j.Url = strings.TrimSuffix(conf["url"], "/")
+	j.Client = NewRestClient("Jira", j.Url+JIRA_API_PATH, header)
+	j.Project = conf["project"]
+	j.Jql = conf["jql"]
+	if j.Jql == "" {
+		j.Jql = DEFAULT_JIRA_JQL
+	}
+	return nil
+}
+
+func (j *JiraProvider) ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error) {
+	if commandName == "get" {
+		return j.Get(callbacks)
+	} else if commandName == "search" {
+		return j.Search(callbacks)
+	} else if commandName == "describe" {
+		return j.Describe(callbacks)
+	} else if commandName == "create" {
+		return j.Create(callbacks)
+	} else if commandName == "update" {
+		return j.Update(callbacks)
+	} else if commandName == "comment" {
+		return j.Comment(callbacks)
+	} else if commandName == "close" {
+		return j.Close(callbacks)
+	}
+	return nil, fmt.Errorf("could not find command %s", commandName)
+}
+
+type JiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		Created     string   `json:"created"`
+		Labels      []string `json:"labels"`
+		IssueType   struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Status   JiraStatus `json:"status"`
+		Assignee *JiraUser  `json:"assignee"`
+		Reporter *JiraUser  `json:"reporter"`
+		Comment  struct {
+			Comments []struct {
+				Author  JiraUser `json:"author"`
+				Body    string   `json:"body"`
+				Created string   `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+type JiraStatus struct {
+	Name           string `json:"name"`
+	StatusCategory struct {
+		Key string `json:"key"`
+	} `json:"statusCategory"`
+}
+
+type JiraUser struct {
+	AccountId   string `json:"accountId"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type JiraTransition struct {
+	Id   string     `json:"id"`
+	Name string     `json:"name"`
+	To   JiraStatus `json:"to"`
+}
+
+func (j *JiraProvider) Get(c api.Callbacks) (interface{}, error) {
+	jql, err := c.Scope(map[string]interface{}{"project": j.Project}).Template(j.Jql)
+	if err != nil {
+		return nil, err
+	}
+	return j.search(jql)
+}
+
+func (j *JiraProvider) Search(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "text",
+			Description: "Search text",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	jql := fmt.Sprintf("project = %s", jqlString(j.Project))
+	if inputs["text"] != "" {
+		jql += fmt.Sprintf(" AND text ~ %s", jqlString(inputs["text"]))
+	}
+	return j.search(jql + " ORDER BY created DESC")
+}
+
+func jqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// search returns the issues of all result pages for the JQL query.
+func (j *JiraProvider) search(jql string) (interface{}, error) {
+	out := []map[string]interface{}{}
+	for startAt := 0; ; {
+		query := url.Values{
+			"jql":        []string{jql},
+			"fields":     []string{JIRA_ISSUE_FIELDS},
+			"startAt":    []string{strconv.Itoa(startAt)},
+			"maxResults": []string{strconv.Itoa(REST_PAGE_SIZE)},
+		}
+		var result struct {
+			Total  int         `json:"total"`
+			Issues []JiraIssue `json:"issues"`
+		}
+		if err := j.Client.Get("/search?"+query.Encode(), &result); err != nil {
+			return nil, err
+		}
+		for _, issue := range result.Issues {
+			out = append(out, j.toIssue(issue))
+		}
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			return out, nil
+		}
+	}
+}
+
+func (j *JiraProvider) toIssue(issue JiraIssue) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  issue.Key,
+		"title": issue.Fields.Summary,
+		"type":  j.getType(issue.Fields.IssueType.Name),
+		"state": jiraState(issue.Fields.Status),
+		"date":  issue.Fields.Created,
+	}
+}
+
+// getType returns the type for a Jira issue type, issue types without a
+// mapping are returned in lower case. When types map to the same issue type,
+// the first type in alphabetical order is returned.
+func (j *JiraProvider) getType(issueType string) string {
+	for _, t := range getIssueTypes(j.Types) {
+		if strings.EqualFold(j.Types[t], issueType) {
+			return t
+		}
+	}
+	return strings.ToLower(issueType)
+}
+
+func jiraState(status JiraStatus) string {
+	if status.StatusCategory.Key == JIRA_DONE {
+		return "closed"
+	}
+	return "open"
+}
+
+// issueKey returns the key of an issue, the project key is added to issue
+// numbers.
+func (j *JiraProvider) issueKey(name string) string {
+	if _, err := strconv.Atoi(name); err == nil {
+		return j.Project + "-" + name
+	}
+	return name
+}
+
+func (j *JiraProvider) getIssue(key string) (*JiraIssue, error) {
+	var issue JiraIssue
+	err := j.Client.Get("/issue/"+url.PathEscape(key), &issue)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (j *JiraProvider) Describe(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	key := j.issueKey(name)
+	issue, err := j.getIssue(key)
+	if err != nil {
+		return nil, err
+	}
+	transitions, err := j.getTransitions(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []string
+	for _, comment := range issue.Fields.Comment.Comments {
+		comments = append(comments, fmt.Sprintf("%s on %s:\n%s", comment.Author.DisplayName, comment.Created, comment.Body))
+	}
+	var transitionNames []string
+	for _, transition := range transitions {
+		transitionNames = append(transitionNames, transition.Name)
+	}
+	author := ""
+	if issue.Fields.Reporter != nil {
+		author = issue.Fields.Reporter.DisplayName
+	}
+	assignee := ""
+	if issue.Fields.Assignee != nil {
+		assignee = issue.Fields.Assignee.DisplayName
+	}
+	result := j.toIssue(*issue)
+	result["status"] = issue.Fields.Status.Name
+	result["author"] = author
+	result["url"] = j.Url + "/browse/" + issue.Key
+	result["description"] = issue.Fields.Description
+	result["labels"] = strings.Join(issue.Fields.Labels, ",")
+	result["assignees"] = assignee
+	result["transitions"] = strings.Join(transitionNames, ",")
+	result["comments"] = strings.Join(comments, "\n\n")
+	return result, nil
+}
+
+func (j *JiraProvider) Create(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "title",
+			Description: "",
+			Type:        api.Text,
+		},
+		typeInputRequest(j.Types),
+		{
+			Name:        "description",
+			Description: "",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	err = j.Client.Send(http.MethodPost, "/issue", map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.Project},
+			"summary":     inputs["title"],
+			"description": inputs["description"],
+			"issuetype":   map[string]string{"name": getTypeLabel(j.Types, inputs["type"])},
+		},
+	}, &created)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := j.getIssue(created.Key)
+	if err != nil {
+		return nil, err
+	}
+	return j.toIssue(*issue), nil
+}
+
+// Update transitions the issue to the state and changes the labels and the
+// assignee. Jira issues have a single assignee.
+func (j *JiraProvider) Update(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueUpdateInputRequests())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIssueUpdate(inputs); err != nil {
+		return nil, err
+	}
+	key := j.issueKey(inputs["name"])
+	if _, err := j.getIssue(key); err != nil {
+		return nil, err
+	}
+	if inputs["labels"] != "" {
+		err := j.Client.Send(http.MethodPut, "/issue/"+url.PathEscape(key), map[string]interface{}{
+			"fields": map[string]interface{}{"labels": config.SplitList(inputs["labels"])},
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inputs["assignees"] != "" {
+		if err := j.assign(key, inputs["assignees"]); err != nil {
+			return nil, err
+		}
+	}
+	if inputs["state"] != "" {
+		if err := j.transition(key, inputs["state"]); err != nil {
+			return nil, err
+		}
+	}
+	issue, err := j.getIssue(key)
+	if err != nil {
+		return nil, err
+	}
+	return j.toIssue(*issue), nil
+}
+
+func (j *JiraProvider) assign(key, assignees string) error {
+	names := config.SplitList(assignees)
+	if len(names) != 1 {
+		return fmt.Errorf("Jira issues have a single assignee, got %s", assignees)
+	}
+	var users []JiraUser
+	if err := j.Client.Get("/user/search?"+url.Values{"query": names}.Encode(), &users); err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("could not find Jira user %s", names[0])
+	}
+	user := map[string]string{"accountId": users[0].AccountId}
+	if users[0].AccountId == "" {
+		user = map[string]string{"name": users[0].Name}
+	}
+	return j.Client.Send(http.MethodPut, "/issue/"+url.PathEscape(key)+"/assignee", user, nil)
+}
+
+func (j *JiraProvider) getTransitions(key string) ([]JiraTransition, error) {
+	var result struct {
+		Transitions []JiraTransition `json:"transitions"`
+	}
+	if err := j.Client.Get("/issue/"+url.PathEscape(key)+"/transitions", &result); err != nil {
+		return nil, err
+	}
+	return result.Transitions, nil
+}
+
+// transition moves the issue to a status in the done category for the closed
+// state, or to a new or in progress status for the open state.
+func (j *JiraProvider) transition(key, state string) error {
+	transitions, err := j.getTransitions(key)
+	if err != nil {
+		return err
+	}
+	categories := []string{JIRA_DONE}
+	if state == "open" {
+		categories = []string{JIRA_NEW, JIRA_IN_PROGRESS}
+	}
+	for _, category := range categories {
+		for _, transition := range transitions {
+			if transition.To.StatusCategory.Key == category {
+				return j.Client.Send(http.MethodPost, "/issue/"+url.PathEscape(key)+"/transitions", map[string]interface{}{
+					"transition": map[string]string{"id": transition.Id},
+				}, nil)
+			}
+		}
+	}
+	var names []string
+	for _, transition := range transitions {
+		names = append(names, transition.Name)
+	}
+	return fmt.Errorf("could not find a transition to %s for %s (available transitions: %s)", state, key, strings.Join(names, ", "))
+}
+
+func (j *JiraProvider) Comment(c api.Callbacks) (interface{}, error) {
+	inputs, err := c.RequestInput(issueCommentInputRequests())
+	if err != nil {
+		return nil, err
+	}
+	key := j.issueKey(inputs["name"])
+	err = j.Client.Send(http.MethodPost, "/issue/"+url.PathEscape(key)+"/comment", map[string]interface{}{
+		"body": inputs["body"],
+	}, nil)
+	if isNotFound(err) {
+		return nil, &api.NoResult{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf("Added comment to issue %s", key), nil
+}
+
+func (j *JiraProvider) Close(c api.Callbacks) (interface{}, error) {
+	name, err := requestIssueName(c)
+	if err != nil {
+		return nil, err
+	}
+	key := j.issueKey(name)
+	if _, err := j.getIssue(key); err != nil {
+		return nil, err
+	}
+	if err := j.transition(key, "closed"); err != nil {
+		return nil, err
+	}
+	issue, err := j.getIssue(key)
+	if err != nil {
+		return nil, err
+	}
+	return j.toIssue(*issue), nil
+}
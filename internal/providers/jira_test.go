@@ -0,0 +1,233 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJiraProviderInit(t *testing.T) {
+	t.Setenv("JIRA_API_TOKEN", "")
+
+	tests := map[string]struct {
+		config map[string]string
+		auth   string
+		errMsg string
+	}{
+		"basic auth": {
+			config: map[string]string{"url": "https://example.atlassian.net/", "project": "DRG", "user": "dev@example.com", "token": "secret"},
+			auth:   "Basic ZGV2QGV4YW1wbGUuY29tOnNlY3JldA==",
+		},
+		"personal access token": {
+			config: map[string]string{"url": "https://jira.example.com", "project": "DRG", "token": "secret"},
+			auth:   "Bearer secret",
+		},
+		"missing url": {
+			config: map[string]string{"project": "DRG", "token": "secret"},
+			errMsg: "could not find field url in config",
+		},
+		"missing project": {
+			config: map[string]string{"url": "https://jira.example.com", "token": "secret"},
+			errMsg: "could not find field project in config",
+		},
+		"invalid url": {
+			config: map[string]string{"url": "jira.example.com", "project": "DRG", "token": "secret"},
+			errMsg: "invalid url jira.example.com in config (expected http:// or https://)",
+		},
+		"invalid project": {
+			config: map[string]string{"url": "https://jira.example.com", "project": "drg", "token": "secret"},
+			errMsg: "invalid project key drg in config (expected uppercase letters and digits)",
+		},
+		"missing token": {
+			config: map[string]string{"url": "https://jira.example.com", "project": "DRG"},
+			errMsg: "could not find field token in config or JIRA_API_TOKEN",
+		},
+		"invalid types": {
+			config: map[string]string{"url": "https://jira.example.com", "project": "DRG", "token": "secret", "types": "Bug"},
+			errMsg: "invalid type label Bug (expected <type>=<label>)",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		p := &JiraProvider{}
+		err := p.Init(test.config)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.auth, p.Client.Header.Get("Authorization"))
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func jiraIssueJson(key, summary, issueType, status, category string) string {
+	return fmt.Sprintf(`{"key": "%s", "fields": {"summary": "%s", "created": "2022-01-01T10:00:00.000+0000", "issuetype": {"name": "%s"}, "status": {"name": "%s", "statusCategory": {"key": "%s"}}}}`, key, summary, issueType, status, category)
+}
+
+func newJiraServer(t *testing.T) (string, *[]stubRequest) {
+	status := "To Do"
+	category := JIRA_NEW
+	server, requests := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /rest/api/2/search":
+			if r.URL.Query().Get("startAt") == "0" {
+				fmt.Fprintf(w, `{"total": 2, "issues": [%s]}`, jiraIssueJson("DRG-1", "Crash", "Bug", "To Do", JIRA_NEW))
+			} else {
+				fmt.Fprintf(w, `{"total": 2, "issues": [%s]}`, jiraIssueJson("DRG-2", "Dark mode", "Epic", "In Progress", JIRA_IN_PROGRESS))
+			}
+		case "GET /rest/api/2/issue/DRG-1":
+			fmt.Fprintf(w, `{"key": "DRG-1", "fields": {"summary": "Crash", "description": "It crashes", "created": "2022-01-01T10:00:00.000+0000", "labels": ["urgent"],
+				"issuetype": {"name": "Bug"}, "status": {"name": "%s", "statusCategory": {"key": "%s"}},
+				"reporter": {"displayName": "Dev"}, "assignee": {"displayName": "Alice"},
+				"comment": {"comments": [{"author": {"displayName": "Alice"}, "body": "Confirmed", "created": "2022-01-02T10:00:00.000+0000"}]}}}`, status, category)
+		case "GET /rest/api/2/issue/DRG-1/transitions":
+			fmt.Fprint(w, `{"transitions": [
+				{"id": "11", "name": "Start", "to": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}},
+				{"id": "31", "name": "Resolve", "to": {"name": "Done", "statusCategory": {"key": "done"}}}
+			]}`)
+		case "POST /rest/api/2/issue/DRG-1/transitions":
+			status = "Done"
+			category = JIRA_DONE
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /rest/api/2/issue":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": "10001", "key": "DRG-1"}`)
+		case "PUT /rest/api/2/issue/DRG-1", "PUT /rest/api/2/issue/DRG-1/assignee":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /rest/api/2/user/search":
+			if r.URL.Query().Get("query") == "alice" {
+				fmt.Fprint(w, `[{"accountId": "5b10a", "displayName": "Alice"}]`)
+			} else {
+				fmt.Fprint(w, `[]`)
+			}
+		case "POST /rest/api/2/issue/DRG-1/comment":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": "100"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errorMessages": ["Issue does not exist or you do not have permission to see it."], "errors": {}}`)
+		}
+	})
+	return server.URL, requests
+}
+
+func TestJiraProvider(t *testing.T) {
+	u, requests := newJiraServer(t)
+	p := &JiraProvider{}
+	err := p.Init(map[string]string{"url": u, "project": "DRG", "token": "secret"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("get", &callbacksMock{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "DRG-1", "title": "Crash", "type": "bug", "state": "open", "date": "2022-01-01T10:00:00.000+0000"},
+		{"name": "DRG-2", "title": "Dark mode", "type": "epic", "state": "open", "date": "2022-01-01T10:00:00.000+0000"},
+	}, output)
+	assert.Equal(t, "/rest/api/2/search?fields=summary%2Cissuetype%2Cstatus%2Ccreated&jql=project+%3D+%22DRG%22+AND+statusCategory+%21%3D+Done+ORDER+BY+created+DESC&maxResults=100&startAt=0", (*requests)[0].Path)
+	assert.Equal(t, 2, len(*requests))
+
+	_, err = p.ExecuteCommand("search", &callbacksMock{values: map[string]string{"text": `say "hi"`}})
+	assert.Nil(t, err)
+	assert.Contains(t, (*requests)[2].Path, "jql=project+%3D+%22DRG%22+AND+text+~+%22say+%5C%22hi%5C%22%22+ORDER+BY+created+DESC")
+
+	output, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "DRG-1",
+		"title":       "Crash",
+		"type":        "bug",
+		"state":       "open",
+		"status":      "To Do",
+		"date":        "2022-01-01T10:00:00.000+0000",
+		"author":      "Dev",
+		"url":         u + "/browse/DRG-1",
+		"description": "It crashes",
+		"labels":      "urgent",
+		"assignees":   "Alice",
+		"transitions": "Start,Resolve",
+		"comments":    "Alice on 2022-01-02T10:00:00.000+0000:\nConfirmed",
+	}, output)
+
+	c := &callbacksMock{values: map[string]string{"title": "Crash", "type": "feature", "description": "It crashes"}}
+	output, err = p.ExecuteCommand("create", c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bug", "feature"}, c.inputs[1].Values)
+	assert.Equal(t, "DRG-1", output.(map[string]interface{})["name"])
+	var createRequest stubRequest
+	for _, r := range *requests {
+		if r.Method == "POST" && r.Path == "/rest/api/2/issue" {
+			createRequest = r
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]interface{}{"key": "DRG"},
+			"summary":     "Crash",
+			"description": "It crashes",
+			"issuetype":   map[string]interface{}{"name": "Story"},
+		},
+	}, createRequest.Body)
+
+	start := len(*requests)
+	output, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "DRG-1", "state": "closed", "labels": "urgent, ui", "assignees": "alice"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "closed", output.(map[string]interface{})["state"])
+	var bodies []interface{}
+	for _, r := range (*requests)[start:] {
+		if r.Method != "GET" {
+			bodies = append(bodies, r.Body)
+		}
+	}
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"fields": map[string]interface{}{"labels": []interface{}{"urgent", "ui"}}},
+		map[string]interface{}{"accountId": "5b10a"},
+		map[string]interface{}{"transition": map[string]interface{}{"id": "31"}},
+	}, bodies)
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "DRG-1", "assignees": "alice,bob"}})
+	assert.Equal(t, "Jira issues have a single assignee, got alice,bob", fmt.Sprint(err))
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "DRG-1", "assignees": "mallory"}})
+	assert.Equal(t, "could not find Jira user mallory", fmt.Sprint(err))
+
+	output, err = p.ExecuteCommand("comment", &callbacksMock{values: map[string]string{"name": "1", "body": "Fixed"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "Added comment to issue DRG-1", output)
+
+	_, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "DRG-9"}})
+	assert.Equal(t, &api.NoResult{}, err)
+}
+
+func TestJiraGetType(t *testing.T) {
+	p := &JiraProvider{Types: map[string]string{"task": "Task", "defect": "Bug", "bug": "Bug"}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "bug", p.getType("bug"))
+	}
+	assert.Equal(t, "task", p.getType("Task"))
+	assert.Equal(t, "epic", p.getType("Epic"))
+}
+
+func TestJiraTransition(t *testing.T) {
+	u, _ := newJiraServer(t)
+	p := &JiraProvider{}
+	err := p.Init(map[string]string{"url": u, "project": "DRG", "token": "secret"})
+	assert.Nil(t, err)
+
+	err = p.transition("DRG-1", "open")
+	assert.Nil(t, err)
+
+	err = p.transition("DRG-9", "closed")
+	assert.Equal(t, "Jira API error (404): Issue does not exist or you do not have permission to see it.", fmt.Sprint(err))
+
+	_, err = p.ExecuteCommand("close", &callbacksMock{values: map[string]string{"name": "DRG-1"}})
+	assert.Nil(t, err)
+}
+
+func TestJiraErrorMessage(t *testing.T) {
+	msg := errorMessage(http.StatusBadRequest, []byte(`{"errorMessages": ["Invalid JQL"], "errors": {"summary": "Summary is required", "issuetype": "Unknown type"}}`))
+	assert.Equal(t, "Invalid JQL, issuetype: Unknown type, summary: Summary is required", msg)
+}
@@ -0,0 +1,454 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+)
+
+const DEFAULT_ROLLOUT_TIMEOUT = "5m"
+
+var MANIFEST_DIRS = []string{"k8s", "kubernetes", "manifests", "deploy"}
+
+var DEPLOYMENT_KIND_RE = regexp.MustCompile(`(?m)^kind:\s*Deployment\s*$`)
+
+// KubernetesProvider manages the deployments in a Kubernetes cluster with
+// kubectl. The config can contain:
+//
+//	context:     the kubeconfig context (default: the current context)
+//	kubeconfig:  the kubeconfig file (default: KUBECONFIG or ~/.kube/config)
+//	namespace:   the namespace (default: the namespace of the context)
+//	selector:    a label selector for the deployments
+//	container:   the container with the version (default: the first container)
+//	timeout:     the time to wait for a rollout (default: 5m)
+//	kubectl:     the kubectl executable (default: kubectl)
+type KubernetesProvider struct {
+	Context    string
+	Kubeconfig string
+	Namespace  string
+	Selector   string
+	Container  string
+	Timeout    string
+	Kubectl    string
+}
+
+func (k *KubernetesProvider) Name() string {
+	return "kubernetes"
+}
+
+// Discover adds the provider when there are Kubernetes manifests in the
+// project or when kubectl has a current context.
+func (k *KubernetesProvider) Discover(callbacks api.Callbacks) error {
+	manifests := findManifestDir()
+	output, err := exec.Command("kubectl", "config", "current-context").Output()
+	if err != nil {
+		if manifests == "" {
+			return err
+		}
+		output = nil
+	}
+	context := strings.TrimSpace(string(output))
+	if manifests == "" && context == "" {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Kubernetes context %s detected", context)
+	if manifests != "" {
+		msg = fmt.Sprintf("Kubernetes manifests detected in %s", manifests)
+	}
+	confirmed, err := callbacks.Confirm("%s, do you want to add kubernetes?", msg)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+	err = callbacks.Log(api.Info, "Adding kubernetes as a provider")
+	if err != nil {
+		return err
+	}
+	var providerConfig map[string]string
+	if context != "" {
+		providerConfig = map[string]string{"context": context}
+	}
+	return callbacks.AddProviderToDredgefile("deploy", "kubernetes", providerConfig)
+}
+
+func findManifestDir() string {
+	for _, dir := range MANIFEST_DIRS {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.y*ml"))
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err == nil && DEPLOYMENT_KIND_RE.Match(b) {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+func (k *KubernetesProvider) Init(config map[string]string) error {
+	k.Context = config["context"]
+	k.Kubeconfig = config["kubeconfig"]
+	k.Namespace = config["namespace"]
+	k.Selector = config["selector"]
+	k.Container = config["container"]
+	k.Timeout = config["timeout"]
+	if k.Timeout == "" {
+		k.Timeout = DEFAULT_ROLLOUT_TIMEOUT
+	}
+	k.Kubectl = config["kubectl"]
+	if k.Kubectl == "" {
+		k.Kubectl = "kubectl"
+	}
+	return nil
+}
+
+func (k *KubernetesProvider) ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error) {
+	if commandName == "get" {
+		return k.Get(callbacks)
+	} else if commandName == "describe" {
+		return k.Describe(callbacks)
+	} else if commandName == "update" {
+		return k.Update(callbacks)
+	} else if commandName == "logs" {
+		return k.Logs(callbacks)
+	} else if commandName == "restart" {
+		return k.Restart(callbacks)
+	}
+	return nil, fmt.Errorf("could not find command %s", commandName)
+}
+
+type KubernetesDeployment struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Selector struct {
+			MatchLabels map[string]string `json:"matchLabels"`
+		} `json:"selector"`
+		Template struct {
+			Spec struct {
+				Containers []KubernetesContainer `json:"containers"`
+			} `json:"spec"`
+		} `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas     int `json:"readyReplicas"`
+		UpdatedReplicas   int `json:"updatedReplicas"`
+		AvailableReplicas int `json:"availableReplicas"`
+		Conditions        []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+type KubernetesContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type KubernetesPod struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			Ready        bool `json:"ready"`
+			RestartCount int  `json:"restartCount"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type KubernetesEvent struct {
+	Type          string `json:"type"`
+	Reason        string `json:"reason"`
+	Message       string `json:"message"`
+	LastTimestamp string `json:"lastTimestamp"`
+}
+
+func (k *KubernetesProvider) Get(c api.Callbacks) (interface{}, error) {
+	args := []string{"get", "deployments", "-o", "json"}
+	if k.Selector != "" {
+		args = append(args, "-l", k.Selector)
+	}
+	var list struct {
+		Items []KubernetesDeployment `json:"items"`
+	}
+	if err := k.getJson(&list, args...); err != nil {
+		return nil, err
+	}
+	out := []map[string]interface{}{}
+	for _, d := range list.Items {
+		out = append(out, k.toDeploy(d))
+	}
+	return out, nil
+}
+
+func (k *KubernetesProvider) toDeploy(d KubernetesDeployment) map[string]interface{} {
+	replicas := 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	version := ""
+	if container := k.getContainer(d); container != nil {
+		_, version = splitImage(container.Image)
+	}
+	return map[string]interface{}{
+		"name":      d.Metadata.Name,
+		"version":   version,
+		"instances": strconv.Itoa(replicas),
+		"type":      "kubernetes",
+	}
+}
+
+// getContainer returns the configured container or the first container of
+// the deployment.
+func (k *KubernetesProvider) getContainer(d KubernetesDeployment) *KubernetesContainer {
+	for _, container := range d.Spec.Template.Spec.Containers {
+		if k.Container == "" || container.Name == k.Container {
+			return &container
+		}
+	}
+	return nil
+}
+
+// splitImage returns the image repository and the tag or digest.
+func splitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+func (k *KubernetesProvider) Describe(c api.Callbacks) (interface{}, error) {
+	name, err := requestDeployName(c)
+	if err != nil {
+		return nil, err
+	}
+	d, err := k.getDeployment(name)
+	if err != nil {
+		return nil, err
+	}
+	result := k.toDeploy(*d)
+	result["provider"] = k.Name()
+	result["namespace"] = d.Metadata.Namespace
+	if container := k.getContainer(*d); container != nil {
+		result["image"] = container.Image
+	}
+	result["ready"] = fmt.Sprintf("%d/%s", d.Status.ReadyReplicas, result["instances"])
+
+	var conditions []string
+	for _, condition := range d.Status.Conditions {
+		conditions = append(conditions, fmt.Sprintf("%s=%s", condition.Type, condition.Status))
+	}
+	result["status"] = strings.Join(conditions, ",")
+
+	var selector []string
+	for key, value := range d.Spec.Selector.MatchLabels {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	var pods struct {
+		Items []KubernetesPod `json:"items"`
+	}
+	if err := k.getJson(&pods, "get", "pods", "-o", "json", "-l", strings.Join(selector, ",")); err != nil {
+		return nil, err
+	}
+	var podLines []string
+	for _, pod := range pods.Items {
+		ready, restarts := 0, 0
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Ready {
+				ready++
+			}
+			restarts += status.RestartCount
+		}
+		podLines = append(podLines, fmt.Sprintf("%s %s %d/%d ready, %d restarts", pod.Metadata.Name, pod.Status.Phase, ready, len(pod.Status.ContainerStatuses), restarts))
+	}
+	result["pods"] = strings.Join(podLines, "\n")
+
+	var events struct {
+		Items []KubernetesEvent `json:"items"`
+	}
+	if err := k.getJson(&events, "get", "events", "-o", "json", "--field-selector", "involvedObject.kind=Deployment,involvedObject.name="+name); err != nil {
+		return nil, err
+	}
+	var eventLines []string
+	for _, event := range events.Items {
+		eventLines = append(eventLines, fmt.Sprintf("%s %s %s: %s", event.LastTimestamp, event.Type, event.Reason, event.Message))
+	}
+	result["events"] = strings.Join(eventLines, "\n")
+	return result, nil
+}
+
+// Update changes the image tag and the replicas of the deployment and waits
+// for the rollout to finish.
+func (k *KubernetesProvider) Update(c api.Callbacks) (interface{}, error) {
+	name, err := requestDeployName(c)
+	if err != nil {
+		return nil, err
+	}
+	d, err := k.getDeployment(name)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "version",
+			Description: "Version",
+			Type:        api.Text,
+		},
+		{
+			Name:        "instances",
+			Description: "Number of instances",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inputs["version"] == "" && inputs["instances"] == "" {
+		return nil, fmt.Errorf("nothing to update, provide a version or instances")
+	}
+
+	if inputs["version"] != "" {
+		container := k.getContainer(*d)
+		if container == nil {
+			return nil, fmt.Errorf("could not find container %s in deployment %s", k.Container, name)
+		}
+		image, _ := splitImage(container.Image)
+		c.Log(api.Info, "Updating %s to %s:%s", name, image, inputs["version"])
+		if _, err := k.run("set", "image", "deployment/"+name, fmt.Sprintf("%s=%s:%s", container.Name, image, inputs["version"])); err != nil {
+			return nil, err
+		}
+	}
+	if inputs["instances"] != "" {
+		if _, err := strconv.Atoi(inputs["instances"]); err != nil {
+			return nil, fmt.Errorf("invalid value (%s) for input instances (expected a number)", inputs["instances"])
+		}
+		c.Log(api.Info, "Scaling %s to %s instances", name, inputs["instances"])
+		if _, err := k.run("scale", "deployment/"+name, "--replicas="+inputs["instances"]); err != nil {
+			return nil, err
+		}
+	}
+	return k.waitForRollout(name, c)
+}
+
+func (k *KubernetesProvider) Logs(c api.Callbacks) (interface{}, error) {
+	name, err := requestDeployName(c)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.getDeployment(name); err != nil {
+		return nil, err
+	}
+	output, err := k.run("logs", "deployment/"+name, "--all-containers")
+	if err != nil {
+		return nil, err
+	}
+	return string(output), nil
+}
+
+func (k *KubernetesProvider) Restart(c api.Callbacks) (interface{}, error) {
+	name, err := requestDeployName(c)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := k.getDeployment(name); err != nil {
+		return nil, err
+	}
+	c.Log(api.Info, "Restarting %s", name)
+	if _, err := k.run("rollout", "restart", "deployment/"+name); err != nil {
+		return nil, err
+	}
+	return k.waitForRollout(name, c)
+}
+
+func (k *KubernetesProvider) waitForRollout(name string, c api.Callbacks) (interface{}, error) {
+	c.Log(api.Info, "Waiting for the rollout of %s", name)
+	if _, err := k.run("rollout", "status", "deployment/"+name, "--timeout="+k.Timeout); err != nil {
+		return nil, err
+	}
+	d, err := k.getDeployment(name)
+	if err != nil {
+		return nil, err
+	}
+	return k.toDeploy(*d), nil
+}
+
+func (k *KubernetesProvider) getDeployment(name string) (*KubernetesDeployment, error) {
+	var d KubernetesDeployment
+	if err := k.getJson(&d, "get", "deployment", name, "-o", "json"); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (k *KubernetesProvider) getJson(out interface{}, args ...string) error {
+	output, err := k.run(args...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(output, out); err != nil {
+		return fmt.Errorf("invalid output from kubectl: %v", err)
+	}
+	return nil
+}
+
+// run executes kubectl with the context, kubeconfig and namespace of the
+// config. Resources that don't exist result in NoResult.
+func (k *KubernetesProvider) run(args ...string) ([]byte, error) {
+	var global []string
+	if k.Kubeconfig != "" {
+		global = append(global, "--kubeconfig", k.Kubeconfig)
+	}
+	if k.Context != "" {
+		global = append(global, "--context", k.Context)
+	}
+	if k.Namespace != "" {
+		global = append(global, "--namespace", k.Namespace)
+	}
+	output, err := exec.Command(k.Kubectl, append(global, args...)...).Output()
+	if err != nil {
+		if eerr, ok := err.(*exec.ExitError); ok {
+			stderr := strings.TrimSpace(string(eerr.Stderr))
+			if strings.Contains(stderr, "(NotFound)") {
+				return nil, &api.NoResult{}
+			}
+			return nil, fmt.Errorf("kubectl %s failed: %v %s", args[0], err, stderr)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+func requestDeployName(c api.Callbacks) (string, error) {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "name",
+			Description: "Name",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return inputs["name"], nil
+}
@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := map[string][]string{
+		"nginx":                         {"nginx", "latest"},
+		"nginx:1.23":                    {"nginx", "1.23"},
+		"registry:5000/team/app:v2":     {"registry:5000/team/app", "v2"},
+		"registry:5000/team/app":        {"registry:5000/team/app", "latest"},
+		"app@sha256:0123456789abcdef":   {"app", "sha256:0123456789abcdef"},
+		"ghcr.io/dredge-dev/drg:v0.1.0": {"ghcr.io/dredge-dev/drg", "v0.1.0"},
+	}
+
+	for image, expected := range tests {
+		repo, tag := splitImage(image)
+		assert.Equal(t, expected, []string{repo, tag})
+	}
+}
+
+func TestKubernetesProvider(t *testing.T) {
+	kubectl, log := writeHelperScript(t, "kubectl", "TestKubectlHelperProcess")
+	p := &KubernetesProvider{}
+	err := p.Init(map[string]string{"kubectl": kubectl, "context": "staging", "namespace": "shop", "selector": "team=web"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("get", &callbacksMock{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "web", "version": "1.2.0", "instances": "3", "type": "kubernetes"},
+		{"name": "worker", "version": "latest", "instances": "1", "type": "kubernetes"},
+	}, output)
+	assert.Equal(t, []string{"--context staging --namespace shop get deployments -o json -l team=web"}, readHelperLog(t, log))
+
+	output, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "web"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":      "web",
+		"version":   "1.2.0",
+		"instances": "3",
+		"type":      "kubernetes",
+		"provider":  "kubernetes",
+		"namespace": "shop",
+		"image":     "registry.example.com/web:1.2.0",
+		"ready":     "2/3",
+		"status":    "Available=True,Progressing=True",
+		"pods":      "web-1 Running 1/1 ready, 0 restarts\nweb-2 Pending 0/1 ready, 2 restarts",
+		"events":    "2022-01-01T10:00:00Z Normal ScalingReplicaSet: Scaled up replica set web-5d9 to 3",
+	}, output)
+	assert.Equal(t, []string{
+		"--context staging --namespace shop get deployment web -o json",
+		"--context staging --namespace shop get pods -o json -l app=web,tier=frontend",
+		"--context staging --namespace shop get events -o json --field-selector involvedObject.kind=Deployment,involvedObject.name=web",
+	}, readHelperLog(t, log))
+
+	c := &callbacksMock{values: map[string]string{"name": "web", "version": "1.3.0", "instances": "5"}}
+	output, err = p.ExecuteCommand("update", c)
+	assert.Nil(t, err)
+	assert.Equal(t, "web", output.(map[string]interface{})["name"])
+	assert.Equal(t, []string{
+		"--context staging --namespace shop get deployment web -o json",
+		"--context staging --namespace shop set image deployment/web web=registry.example.com/web:1.3.0",
+		"--context staging --namespace shop scale deployment/web --replicas=5",
+		"--context staging --namespace shop rollout status deployment/web --timeout=5m",
+		"--context staging --namespace shop get deployment web -o json",
+	}, readHelperLog(t, log))
+	assert.Equal(t, []string{
+		"INFO Updating web to registry.example.com/web:1.3.0",
+		"INFO Scaling web to 5 instances",
+		"INFO Waiting for the rollout of web",
+	}, c.logs)
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "web"}})
+	assert.Equal(t, "nothing to update, provide a version or instances", fmt.Sprint(err))
+
+	_, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "api"}})
+	assert.Equal(t, &api.NoResult{}, err)
+
+	_, err = p.ExecuteCommand("restart", &callbacksMock{values: map[string]string{"name": "worker"}})
+	assert.Equal(t, "kubectl rollout failed: exit status 1 error: deployment \"worker\" exceeded its progress deadline", fmt.Sprint(err))
+}
+
+// TestKubectlHelperProcess is started by the kubectl executable created in the
+// tests and prints the kubectl output for the arguments.
+func TestKubectlHelperProcess(t *testing.T) {
+	args, ok := helperArgs()
+	if !ok {
+		return
+	}
+	for len(args) > 1 && strings.HasPrefix(args[0], "--") {
+		args = args[2:]
+	}
+
+	web := `{"metadata": {"name": "web", "namespace": "shop"}, "spec": {"replicas": 3, "selector": {"matchLabels": {"tier": "frontend", "app": "web"}},
+		"template": {"spec": {"containers": [{"name": "web", "image": "registry.example.com/web:1.2.0"}, {"name": "proxy", "image": "envoy:1.25"}]}}},
+		"status": {"readyReplicas": 2, "conditions": [{"type": "Available", "status": "True"}, {"type": "Progressing", "status": "True"}]}}`
+	worker := `{"metadata": {"name": "worker", "namespace": "shop"}, "spec": {"template": {"spec": {"containers": [{"name": "worker", "image": "worker"}]}}}}`
+
+	switch strings.Join(args, " ") {
+	case "get deployments -o json -l team=web":
+		fmt.Printf(`{"items": [%s, %s]}`, web, worker)
+	case "get deployment web -o json":
+		fmt.Print(web)
+	case "get deployment worker -o json":
+		fmt.Print(worker)
+	case "get pods -o json -l app=web,tier=frontend":
+		fmt.Print(`{"items": [
+			{"metadata": {"name": "web-1"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true, "restartCount": 0}]}},
+			{"metadata": {"name": "web-2"}, "status": {"phase": "Pending", "containerStatuses": [{"ready": false, "restartCount": 2}]}}
+		]}`)
+	case "get events -o json --field-selector involvedObject.kind=Deployment,involvedObject.name=web":
+		fmt.Print(`{"items": [{"type": "Normal", "reason": "ScalingReplicaSet", "message": "Scaled up replica set web-5d9 to 3", "lastTimestamp": "2022-01-01T10:00:00Z"}]}`)
+	case "set image deployment/web web=registry.example.com/web:1.3.0", "scale deployment/web --replicas=5",
+		"rollout status deployment/web --timeout=5m", "rollout restart deployment/worker":
+	case "rollout status deployment/worker --timeout=5m":
+		fmt.Fprint(os.Stderr, `error: deployment "worker" exceeded its progress deadline`)
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, `Error from server (NotFound): deployments.apps "%s" not found`, args[len(args)-3])
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
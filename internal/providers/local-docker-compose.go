@@ -1,119 +1,208 @@
 package providers
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/dredge-dev/dredge/internal/api"
+	"gopkg.in/yaml.v3"
 )
 
-var PORTS_RE = regexp.MustCompile(`0.0.0.0:([0-9]+)->([0-9]+)/tcp`)
-
+var COMPOSE_FILES = []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}
+
+// LocalDockerComposeProvider manages a docker compose project as a single
+// deploy. The config contains:
+//
+//	env:      the name of the deploy
+//	path:     the compose file or the directory with the compose file
+//	image:    the image with the version of the deploy (default: the image of
+//	          the first service)
+//	service:  the service that is scaled (default: the service with the image)
+//	proto:    set to http to show the url of the service
+//	command:  the compose command (default: docker compose, or docker-compose
+//	          when the compose plugin is not installed)
 type LocalDockerComposeProvider struct {
 	Env          string
 	Path         string
 	Image        string
+	Service      string
 	Proto        string
+	Command      []string
 	absolutePath string
 }
 
+type ComposeFile struct {
+	Services map[string]ComposeService `yaml:"services"`
+}
+
+type ComposeService struct {
+	Image  string        `yaml:"image"`
+	Ports  []interface{} `yaml:"ports"`
+	Deploy struct {
+		Replicas *int `yaml:"replicas"`
+	} `yaml:"deploy"`
+}
+
+type ComposeContainer struct {
+	Name       string
+	Service    string
+	State      string
+	Publishers []struct {
+		URL           string
+		TargetPort    int
+		PublishedPort int
+		Protocol      string
+	}
+}
+
 func (l *LocalDockerComposeProvider) Name() string {
 	return "local-docker-compose"
 }
 
 func (l *LocalDockerComposeProvider) Discover(callbacks api.Callbacks) error {
-	path := "docker-compose.yml"
-	info, err := os.Stat(path)
-	if err == nil && info.Mode().IsRegular() {
-		confirmed, err := callbacks.Confirm("%s detected, do you want to add local-docker-compose?", path)
+	path, err := findComposeFile(".")
+	if err != nil {
+		return nil
+	}
+	confirmed, err := callbacks.Confirm("%s detected, do you want to add local-docker-compose?", path)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+	compose, err := readComposeFile(path)
+	if err != nil {
+		return err
+	}
+	images := compose.getImages()
+	if len(images) == 0 {
+		return fmt.Errorf("could not find image in %s", path)
+	}
+	image := images[0]
+	if len(images) > 1 {
+		output, err := callbacks.RequestInput([]api.InputRequest{
+			{
+				Name:        "image",
+				Description: "Select the image for your service",
+				Type:        api.Select,
+				Values:      images,
+			},
+		})
 		if err != nil {
 			return err
 		}
-		if confirmed {
-			images, err := getImagesInDockerComposeFile(path)
-			if err != nil {
-				return err
-			}
-			if len(images) == 0 {
-				return fmt.Errorf("could not find image in docker-compose.yml")
-			}
-			var image string
-			if len(images) > 1 {
-				output, err := callbacks.RequestInput([]api.InputRequest{
-					{
-						Name:        "image",
-						Description: "Select the image for your service",
-						Type:        api.Select,
-						Values:      images,
-					},
-				})
-				if err != nil {
-					return err
-				}
-				image = output["image"]
-			} else {
-				image = images[0]
-			}
-			err = callbacks.Log(api.Info, "Adding local-docker-compose as a provider")
-			if err != nil {
-				return err
-			}
-			err = callbacks.AddProviderToDredgefile("deploy", "local-docker-compose", map[string]string{
-				"path":  ".",
-				"env":   "local",
-				"image": image,
-			})
-			if err != nil {
-				return err
-			}
+		image = output["image"]
+	}
+	err = callbacks.Log(api.Info, "Adding local-docker-compose as a provider")
+	if err != nil {
+		return err
+	}
+	return callbacks.AddProviderToDredgefile("deploy", "local-docker-compose", map[string]string{
+		"path":  ".",
+		"env":   "local",
+		"image": image,
+	})
+}
+
+func findComposeFile(dir string) (string, error) {
+	for _, name := range COMPOSE_FILES {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
 		}
 	}
-	return nil
+	return "", fmt.Errorf("could not find a compose file in %s", dir)
 }
 
-func getImagesInDockerComposeFile(path string) ([]string, error) {
-	file, err := os.Open(path)
+func readComposeFile(path string) (*ComposeFile, error) {
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	var compose ComposeFile
+	if err := yaml.Unmarshal(b, &compose); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	return &compose, nil
+}
 
+// getImages returns the images of the services without the tag.
+func (c *ComposeFile) getImages() []string {
 	var images []string
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "image:") {
-			parts := strings.Split(line, ":")
-			image := strings.TrimSpace(parts[1])
-			images = append(images, image)
+	for _, name := range c.getServiceNames() {
+		if image := c.Services[name].Image; image != "" {
+			repo, _ := splitImage(image)
+			images = append(images, repo)
 		}
 	}
+	return images
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+func (c *ComposeFile) getServiceNames() []string {
+	var names []string
+	for name := range c.Services {
+		names = append(names, name)
 	}
-
-	return images, nil
+	sort.Strings(names)
+	return names
 }
 
 func (l *LocalDockerComposeProvider) Init(config map[string]string) error {
-	err := checkConfig(config, []string{"env", "path", "image"})
+	err := checkConfig(config, []string{"env", "path"})
 	if err != nil {
 		return err
 	}
 	l.Env = config["env"]
 	l.Path = config["path"]
 	l.Image = config["image"]
+	l.Service = config["service"]
 	l.Proto = config["proto"]
+	l.Command = strings.Fields(config["command"])
 	l.absolutePath, err = l.getAbsolutePath()
-	return err
+	if err != nil {
+		return err
+	}
+	compose, err := readComposeFile(l.absolutePath)
+	if err != nil {
+		return err
+	}
+	if l.Service == "" {
+		l.Service, err = l.findService(compose)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := compose.Services[l.Service]; !ok {
+		return fmt.Errorf("could not find service %s in %s", l.Service, l.absolutePath)
+	}
+	return nil
+}
+
+// findService returns the service with the configured image or the first
+// service with an image.
+func (l *LocalDockerComposeProvider) findService(compose *ComposeFile) (string, error) {
+	for _, name := range compose.getServiceNames() {
+		image := compose.Services[name].Image
+		if image == "" {
+			continue
+		}
+		if repo, _ := splitImage(image); l.Image == "" || repo == l.Image {
+			return name, nil
+		}
+	}
+	if l.Image != "" {
+		return "", fmt.Errorf("could not find a service with image %s in %s", l.Image, l.absolutePath)
+	}
+	return "", fmt.Errorf("could not find a service with an image in %s", l.absolutePath)
 }
 
 func (l *LocalDockerComposeProvider) ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error) {
@@ -123,95 +212,131 @@ func (l *LocalDockerComposeProvider) ExecuteCommand(commandName string, callback
 		return l.Describe(callbacks)
 	} else if commandName == "update" {
 		return l.Update(callbacks)
+	} else if commandName == "logs" {
+		return l.Logs(callbacks)
+	} else if commandName == "restart" {
+		return l.Restart(callbacks)
 	}
 	return nil, fmt.Errorf("could not find command %s", commandName)
 }
 
 func (l *LocalDockerComposeProvider) Get(callbacks api.Callbacks) ([]map[string]interface{}, error) {
-	deploy, err := l.get()
+	containers, err := l.ps()
 	if err != nil {
 		return nil, err
 	}
-	return []map[string]interface{}{deploy}, nil
-}
-
-func (l *LocalDockerComposeProvider) get() (map[string]interface{}, error) {
-	instances, err := l.getInstances()
+	deploy, err := l.get(containers)
 	if err != nil {
 		return nil, err
 	}
+	return []map[string]interface{}{deploy}, nil
+}
 
-	version, err := l.getVersion()
+func (l *LocalDockerComposeProvider) get(containers []ComposeContainer) (map[string]interface{}, error) {
+	compose, err := readComposeFile(l.absolutePath)
 	if err != nil {
 		return nil, err
 	}
-
+	_, version := splitImage(compose.Services[l.Service].Image)
 	return map[string]interface{}{
 		"name":      l.Env,
 		"version":   version,
-		"instances": fmt.Sprintf("%d", instances),
+		"instances": strconv.Itoa(countRunning(containers, l.Service)),
 		"type":      "container",
 	}, nil
 }
 
+func countRunning(containers []ComposeContainer, service string) int {
+	count := 0
+	for _, c := range containers {
+		if c.Service == service && c.State == "running" {
+			count++
+		}
+	}
+	return count
+}
+
 func (l *LocalDockerComposeProvider) Describe(c api.Callbacks) (map[string]interface{}, error) {
-	inputs, err := c.RequestInput([]api.InputRequest{
-		{
-			Name:        "name",
-			Description: "Name",
-			Type:        api.Text,
-		},
-	})
+	if err := l.checkName(c); err != nil {
+		return nil, err
+	}
+	containers, err := l.ps()
 	if err != nil {
 		return nil, err
 	}
-	if inputs["name"] != l.Env {
-		return nil, &api.NoResult{}
+	ret, err := l.get(containers)
+	if err != nil {
+		return nil, err
 	}
-	ret, err := l.get()
+	compose, err := readComposeFile(l.absolutePath)
 	if err != nil {
 		return nil, err
 	}
-	ret["provider"] = l.Name()
-	if ret["instances"] != "0" {
-		containers := []string{}
-		ps, err := l.ps()
-		if err != nil {
-			return nil, err
+
+	names := []string{}
+	for _, container := range containers {
+		names = append(names, container.Name)
+	}
+	var services []string
+	for _, service := range compose.getServiceNames() {
+		ports := getPorts(containers, service)
+		if len(ports) == 0 {
+			for _, port := range compose.Services[service].Ports {
+				ports = append(ports, fmt.Sprint(port))
+			}
+		}
+		line := fmt.Sprintf("%s: %d instances", service, countRunning(containers, service))
+		if len(ports) > 0 {
+			line += ", ports " + strings.Join(ports, ",")
 		}
-		for _, line := range ps {
-			parts := strings.Split(line, " ")
-			containers = append(containers, parts[0])
-			ports := parts[len(parts)-1]
-			if PORTS_RE.MatchString(ports) {
-				p := PORTS_RE.FindStringSubmatch(ports)
-				if len(p) > 1 && l.Proto == "http" {
-					ret["url"] = fmt.Sprintf("http://localhost:%s", p[1])
+		services = append(services, line)
+		if service == l.Service && l.Proto == "http" {
+			for _, container := range containers {
+				for _, p := range container.Publishers {
+					if container.Service == service && p.PublishedPort != 0 && ret["url"] == nil {
+						ret["url"] = fmt.Sprintf("http://localhost:%d", p.PublishedPort)
+					}
 				}
 			}
 		}
-		ret["containers"] = containers
 	}
+	ret["provider"] = l.Name()
+	ret["service"] = l.Service
+	ret["services"] = strings.Join(services, "\n")
+	ret["containers"] = names
 	ret["path"] = l.absolutePath
 	return ret, nil
 }
 
+// getPorts returns the published ports of the running containers of the
+// service as <published>-><target>/<protocol>.
+func getPorts(containers []ComposeContainer, service string) []string {
+	var ports []string
+	found := make(map[string]bool)
+	for _, c := range containers {
+		if c.Service != service {
+			continue
+		}
+		for _, p := range c.Publishers {
+			if p.PublishedPort == 0 {
+				continue
+			}
+			port := fmt.Sprintf("%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol)
+			if !found[port] {
+				found[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
 func (l *LocalDockerComposeProvider) Update(c api.Callbacks) (map[string]interface{}, error) {
-	inputs, err := c.RequestInput([]api.InputRequest{
-		{
-			Name:        "name",
-			Description: "name",
-			Type:        api.Text,
-		},
-	})
-	if err != nil {
+	if err := l.checkName(c); err != nil {
 		return nil, err
 	}
-	if inputs["name"] != l.Env {
-		return nil, &api.NoResult{}
-	}
 
-	inputs, err = c.RequestInput([]api.InputRequest{
+	inputs, err := c.RequestInput([]api.InputRequest{
 		{
 			Name:        "version",
 			Description: "version",
@@ -226,6 +351,9 @@ func (l *LocalDockerComposeProvider) Update(c api.Callbacks) (map[string]interfa
 	if err != nil {
 		return nil, err
 	}
+	if inputs["version"] == "" && inputs["instances"] == "" {
+		return nil, fmt.Errorf("nothing to update, provide a version or instances")
+	}
 
 	if inputs["version"] != "" {
 		err = l.updateVersion(inputs["version"])
@@ -234,110 +362,244 @@ func (l *LocalDockerComposeProvider) Update(c api.Callbacks) (map[string]interfa
 		}
 	}
 
-	instances, err := strconv.Atoi(inputs["instances"])
-	if err != nil {
+	args := []string{"up", "-d"}
+	if inputs["instances"] != "" {
+		instances, err := strconv.Atoi(inputs["instances"])
+		if err != nil || instances < 0 {
+			return nil, fmt.Errorf("invalid value (%s) for input instances (expected a number)", inputs["instances"])
+		}
+		c.Log(api.Info, "Scaling %s to %d instances", l.Service, instances)
+		args = append(args, "--scale", fmt.Sprintf("%s=%d", l.Service, instances))
+	} else {
+		c.Log(api.Info, "Updating %s", l.Service)
+	}
+	if _, err := l.compose(append(args, l.Service)...); err != nil {
 		return nil, err
 	}
-	err = l.setInstances(instances, c)
+
+	containers, err := l.ps()
 	if err != nil {
 		return nil, err
 	}
-
-	return l.get()
+	return l.get(containers)
 }
 
-func (l *LocalDockerComposeProvider) setInstances(instances int, c api.Callbacks) error {
-	current, err := l.getInstances()
+func (l *LocalDockerComposeProvider) Logs(c api.Callbacks) (interface{}, error) {
+	if err := l.checkName(c); err != nil {
+		return nil, err
+	}
+	output, err := l.compose("logs", "--no-color")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if instances == current {
-		c.Log(api.Info, "Restarting docker-compose")
-		return l.restart()
+	return string(output), nil
+}
+
+func (l *LocalDockerComposeProvider) Restart(c api.Callbacks) (interface{}, error) {
+	if err := l.checkName(c); err != nil {
+		return nil, err
+	}
+	c.Log(api.Info, "Restarting docker compose")
+	if _, err := l.compose("restart"); err != nil {
+		return nil, err
 	}
-	if instances > current {
-		c.Log(api.Info, "Starting docker-compose")
-		return l.start()
+	containers, err := l.ps()
+	if err != nil {
+		return nil, err
 	}
-	c.Log(api.Info, "Stopping docker-compose")
-	return l.stop()
+	return l.get(containers)
 }
 
-func (l *LocalDockerComposeProvider) compose(command string) ([]byte, error) {
-	cd := ""
-	if len(l.Path) > 0 {
-		cd = fmt.Sprintf("cd %s && ", l.Path)
+// checkName returns NoResult if the requested deploy is not managed by this
+// provider.
+func (l *LocalDockerComposeProvider) checkName(c api.Callbacks) error {
+	inputs, err := c.RequestInput([]api.InputRequest{
+		{
+			Name:        "name",
+			Description: "Name",
+			Type:        api.Text,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if inputs["name"] != l.Env {
+		return &api.NoResult{}
 	}
-	cmd := exec.Command("/bin/bash", "-c", fmt.Sprintf("%sdocker-compose %s", cd, command))
-	return cmd.Output()
+	return nil
 }
 
-func (l *LocalDockerComposeProvider) restart() error {
-	_, err := l.compose("restart")
-	return err
+// getCommand returns the compose command, docker compose (v2) when the compose
+// plugin is installed or docker-compose (v1).
+func (l *LocalDockerComposeProvider) getCommand() []string {
+	if len(l.Command) == 0 {
+		if err := exec.Command("docker", "compose", "version").Run(); err == nil {
+			l.Command = []string{"docker", "compose"}
+		} else {
+			l.Command = []string{"docker-compose"}
+		}
+	}
+	return l.Command
 }
 
-func (l *LocalDockerComposeProvider) start() error {
-	_, err := l.compose("docker-compose up -d")
-	return err
+func (l *LocalDockerComposeProvider) isV1() bool {
+	command := l.getCommand()
+	return filepath.Base(command[0]) == "docker-compose"
 }
 
-func (l *LocalDockerComposeProvider) stop() error {
-	_, err := l.compose("docker-compose down")
-	return err
+func (l *LocalDockerComposeProvider) compose(args ...string) ([]byte, error) {
+	command := l.getCommand()
+	name := strings.Join(append([]string{filepath.Base(command[0])}, command[1:]...), " ")
+	args = append(append(append([]string{}, command[1:]...), "-f", filepath.Base(l.absolutePath)), args...)
+	cmd := exec.Command(command[0], args...)
+	cmd.Dir = filepath.Dir(l.absolutePath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
 }
 
-func (l *LocalDockerComposeProvider) getInstances() (int, error) {
-	containers, err := l.ps()
+// ps returns the running containers of the project. docker compose v2 prints
+// a json array or, since v2.21, a json object per line. docker-compose v1 has
+// no json output, so the containers are listed per service.
+func (l *LocalDockerComposeProvider) ps() ([]ComposeContainer, error) {
+	if l.isV1() {
+		return l.psV1()
+	}
+	output, err := l.compose("ps", "--format", "json")
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	return parseComposePs(output)
+}
 
-	if len(containers) > 0 {
-		return 1, nil
+func parseComposePs(output []byte) ([]ComposeContainer, error) {
+	output = bytes.TrimSpace(output)
+	var containers []ComposeContainer
+	if len(output) == 0 {
+		return containers, nil
+	}
+	if output[0] == '[' {
+		if err := json.Unmarshal(output, &containers); err != nil {
+			return nil, fmt.Errorf("invalid output from docker compose ps: %v", err)
+		}
+		return containers, nil
+	}
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var container ComposeContainer
+		if err := json.Unmarshal(line, &container); err != nil {
+			return nil, fmt.Errorf("invalid output from docker compose ps: %v", err)
+		}
+		containers = append(containers, container)
 	}
-	return 0, nil
+	return containers, nil
 }
 
-func (l *LocalDockerComposeProvider) ps() ([]string, error) {
-	output, err := l.compose("ps")
+func (l *LocalDockerComposeProvider) psV1() ([]ComposeContainer, error) {
+	compose, err := readComposeFile(l.absolutePath)
+	if err != nil {
+		return nil, err
+	}
+	var ids, services []string
+	for _, service := range compose.getServiceNames() {
+		output, err := l.compose("ps", "-q", service)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range strings.Fields(string(output)) {
+			ids = append(ids, id)
+			services = append(services, service)
+		}
+	}
+	running, err := inspectRunning(ids)
 	if err != nil {
 		return nil, err
 	}
-	out := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
-	if len(out) > 2 {
-		return out[2:], nil
+	var containers []ComposeContainer
+	for i, id := range ids {
+		if running[i] {
+			containers = append(containers, ComposeContainer{Name: id, Service: services[i], State: "running"})
+		}
 	}
-	return nil, nil
+	return containers, nil
 }
 
-func (l *LocalDockerComposeProvider) getVersion() (string, error) {
-	b, err := os.ReadFile(l.absolutePath)
-	if err != nil {
-		return "", err
+// inspectRunning returns for every container whether it is running, as
+// docker-compose v1 ps also lists the stopped containers.
+func inspectRunning(ids []string) ([]bool, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	r, err := regexp.Compile(l.Image + `:([^\s]+)`)
+	cmd := exec.Command("docker", append([]string{"inspect", "-f", "{{.State.Running}}"}, ids...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("docker inspect failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	lines := strings.Fields(string(output))
+	if len(lines) != len(ids) {
+		return nil, fmt.Errorf("invalid output from docker inspect: %s", strings.TrimSpace(string(output)))
 	}
-	version := r.FindString(string(b))
-	if version == "" {
-		return "", fmt.Errorf("version could not be determined")
+	running := make([]bool, len(ids))
+	for i, line := range lines {
+		running[i] = line == "true"
 	}
-	return strings.TrimPrefix(version, l.Image+":"), nil
+	return running, nil
 }
 
+// updateVersion changes the tag of the image of the service in the compose
+// file. Only the image value is changed, so comments and formatting are kept.
 func (l *LocalDockerComposeProvider) updateVersion(version string) error {
-	b, err := os.ReadFile(l.absolutePath)
+	b, err := ioutil.ReadFile(l.absolutePath)
 	if err != nil {
 		return err
 	}
-	r, err := regexp.Compile(l.Image + `:([^\s]+)`)
-	if err != nil {
-		return err
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("could not parse %s: %v", l.absolutePath, err)
+	}
+	node := findYamlNode(&root, "services", l.Service, "image")
+	if node == nil {
+		return fmt.Errorf("could not find the image of service %s in %s", l.Service, l.absolutePath)
+	}
+	repo, _ := splitImage(node.Value)
+
+	lines := strings.Split(string(b), "\n")
+	line := lines[node.Line-1]
+	start := node.Column - 1
+	if node.Style == yaml.DoubleQuotedStyle || node.Style == yaml.SingleQuotedStyle {
+		start++
 	}
-	output := r.ReplaceAllString(string(b), l.Image+":"+version)
-	return os.WriteFile(l.absolutePath, []byte(output), 0644)
+	if start > len(line) || !strings.HasPrefix(line[start:], node.Value) {
+		return fmt.Errorf("could not update the image of service %s in %s", l.Service, l.absolutePath)
+	}
+	lines[node.Line-1] = line[:start] + repo + ":" + version + line[start+len(node.Value):]
+	return ioutil.WriteFile(l.absolutePath, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func findYamlNode(node *yaml.Node, path ...string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return findYamlNode(node.Content[0], path...)
+	}
+	if len(path) == 0 {
+		return node
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[0] {
+			return findYamlNode(node.Content[i+1], path[1:]...)
+		}
+	}
+	return nil
 }
 
 func (l *LocalDockerComposeProvider) getAbsolutePath() (string, error) {
@@ -345,8 +607,12 @@ func (l *LocalDockerComposeProvider) getAbsolutePath() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	path := l.Path
 	if fileInfo.IsDir() {
-		return filepath.Join(l.Path, "docker-compose.yml"), nil
+		path, err = findComposeFile(l.Path)
+		if err != nil {
+			return "", err
+		}
 	}
-	return l.Path, nil
+	return filepath.Abs(path)
 }
@@ -0,0 +1,217 @@
+package providers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+const TEST_COMPOSE_FILE = `services:
+  # the web frontend
+  web:
+    image: "registry:5000/shop/web:1.0"
+    ports:
+      - "8080:80"
+  db:
+    image: postgres:14
+  cache:
+    build: .
+`
+
+// createCompose creates a compose project and a compose executable with the
+// given name that runs TestComposeHelperProcess.
+func createCompose(t *testing.T, name string) (string, string, string) {
+	path, log := writeHelperScript(t, name, "TestComposeHelperProcess")
+	project := filepath.Join(filepath.Dir(path), "project")
+	if err := os.Mkdir(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(project, "compose.yaml"), []byte(TEST_COMPOSE_FILE), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, log, project
+}
+
+func TestLocalDockerComposeInit(t *testing.T) {
+	_, _, project := createCompose(t, "docker")
+
+	tests := map[string]struct {
+		config  map[string]string
+		service string
+		errMsg  string
+	}{
+		"default service": {
+			config:  map[string]string{"env": "local", "path": project},
+			service: "db",
+		},
+		"service with image": {
+			config:  map[string]string{"env": "local", "path": project, "image": "registry:5000/shop/web"},
+			service: "web",
+		},
+		"service": {
+			config:  map[string]string{"env": "local", "path": filepath.Join(project, "compose.yaml"), "service": "cache"},
+			service: "cache",
+		},
+		"missing env": {
+			config: map[string]string{"path": project},
+			errMsg: "could not find field env in config",
+		},
+		"unknown image": {
+			config: map[string]string{"env": "local", "path": project, "image": "nginx"},
+			errMsg: fmt.Sprintf("could not find a service with image nginx in %s/compose.yaml", project),
+		},
+		"unknown service": {
+			config: map[string]string{"env": "local", "path": project, "service": "api"},
+			errMsg: fmt.Sprintf("could not find service api in %s/compose.yaml", project),
+		},
+		"missing compose file": {
+			config: map[string]string{"env": "local", "path": filepath.Dir(project)},
+			errMsg: fmt.Sprintf("could not find a compose file in %s", filepath.Dir(project)),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		p := &LocalDockerComposeProvider{}
+		err := p.Init(test.config)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.service, p.Service)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestLocalDockerCompose(t *testing.T) {
+	docker, log, project := createCompose(t, "docker")
+	p := &LocalDockerComposeProvider{}
+	err := p.Init(map[string]string{"env": "local", "path": project, "image": "registry:5000/shop/web", "proto": "http", "command": docker + " compose"})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("get", &callbacksMock{})
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "local", "version": "1.0", "instances": "2", "type": "container"},
+	}, output)
+	assert.Equal(t, []string{"compose -f compose.yaml ps --format json"}, readHelperLog(t, log))
+
+	output, err = p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "local"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":       "local",
+		"version":    "1.0",
+		"instances":  "2",
+		"type":       "container",
+		"provider":   "local-docker-compose",
+		"url":        "http://localhost:8080",
+		"service":    "web",
+		"services":   "cache: 0 instances\ndb: 1 instances\nweb: 2 instances, ports 8080->80/tcp,8081->80/tcp",
+		"containers": []string{"project-web-1", "project-web-2", "project-db-1"},
+		"path":       filepath.Join(project, "compose.yaml"),
+	}, output)
+	readHelperLog(t, log)
+
+	c := &callbacksMock{values: map[string]string{"name": "local", "version": "1.1", "instances": "3"}}
+	_, err = p.ExecuteCommand("update", c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"compose -f compose.yaml up -d --scale web=3 web",
+		"compose -f compose.yaml ps --format json",
+	}, readHelperLog(t, log))
+	assert.Equal(t, []string{"INFO Scaling web to 3 instances"}, c.logs)
+	b, err := ioutil.ReadFile(filepath.Join(project, "compose.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Replace(TEST_COMPOSE_FILE, "web:1.0", "web:1.1", 1), string(b))
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "local", "instances": "many"}})
+	assert.Equal(t, "invalid value (many) for input instances (expected a number)", fmt.Sprint(err))
+
+	_, err = p.ExecuteCommand("update", &callbacksMock{values: map[string]string{"name": "local"}})
+	assert.Equal(t, "nothing to update, provide a version or instances", fmt.Sprint(err))
+
+	_, err = p.ExecuteCommand("restart", &callbacksMock{values: map[string]string{"name": "prod"}})
+	assert.Equal(t, &api.NoResult{}, err)
+}
+
+func TestLocalDockerComposeV1(t *testing.T) {
+	dockerCompose, log, project := createCompose(t, "docker-compose")
+	docker, dockerLog := writeHelperScript(t, "docker", "TestComposeHelperProcess")
+	t.Setenv("PATH", filepath.Dir(docker))
+	p := &LocalDockerComposeProvider{}
+	err := p.Init(map[string]string{"env": "local", "path": project, "service": "web", "command": dockerCompose})
+	assert.Nil(t, err)
+
+	output, err := p.ExecuteCommand("describe", &callbacksMock{values: map[string]string{"name": "local"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", output.(map[string]interface{})["instances"])
+	assert.Equal(t, "cache: 0 instances\ndb: 1 instances\nweb: 2 instances, ports 8080:80", output.(map[string]interface{})["services"])
+	assert.Equal(t, []string{"1a2b3c", "3f4e5a", "8b9c0d"}, output.(map[string]interface{})["containers"])
+	assert.Equal(t, []string{"-f compose.yaml ps -q cache", "-f compose.yaml ps -q db", "-f compose.yaml ps -q web"}, readHelperLog(t, log))
+	assert.Equal(t, []string{"inspect -f {{.State.Running}} 1a2b3c 5e6f7a 3f4e5a 8b9c0d"}, readHelperLog(t, dockerLog))
+
+	_, err = p.ExecuteCommand("restart", &callbacksMock{values: map[string]string{"name": "local"}})
+	assert.Equal(t, "docker-compose failed: exit status 1 ERROR: No containers to restart", fmt.Sprint(err))
+}
+
+func TestParseComposePs(t *testing.T) {
+	tests := map[string]string{
+		"array":  `[{"Name": "app-web-1", "Service": "web", "State": "running"}, {"Name": "app-db-1", "Service": "db", "State": "exited"}]`,
+		"ndjson": "{\"Name\": \"app-web-1\", \"Service\": \"web\", \"State\": \"running\"}\n{\"Name\": \"app-db-1\", \"Service\": \"db\", \"State\": \"exited\"}\n",
+	}
+
+	for testName, output := range tests {
+		t.Logf("Running test case %s", testName)
+		containers, err := parseComposePs([]byte(output))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(containers))
+		assert.Equal(t, 1, countRunning(containers, "web"))
+		assert.Equal(t, 0, countRunning(containers, "db"))
+	}
+
+	containers, err := parseComposePs([]byte("\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(containers))
+
+	_, err = parseComposePs([]byte("NAME COMMAND"))
+	assert.Equal(t, "invalid output from docker compose ps: invalid character 'N' looking for beginning of value", fmt.Sprint(err))
+}
+
+// TestComposeHelperProcess is started by the compose executable created in the
+// tests and prints the docker compose output for the arguments.
+func TestComposeHelperProcess(t *testing.T) {
+	args, ok := helperArgs()
+	if !ok {
+		return
+	}
+	if len(args) > 0 && args[0] == "compose" {
+		args = args[1:]
+	}
+	if len(args) > 1 && args[0] == "-f" {
+		args = args[2:]
+	}
+
+	switch strings.Join(args, " ") {
+	case "ps --format json":
+		fmt.Println(`{"Name": "project-web-1", "Service": "web", "State": "running", "Publishers": [{"URL": "0.0.0.0", "TargetPort": 80, "PublishedPort": 8080, "Protocol": "tcp"}]}`)
+		fmt.Println(`{"Name": "project-web-2", "Service": "web", "State": "running", "Publishers": [{"URL": "0.0.0.0", "TargetPort": 80, "PublishedPort": 8081, "Protocol": "tcp"}]}`)
+		fmt.Println(`{"Name": "project-db-1", "Service": "db", "State": "running", "Publishers": [{"TargetPort": 5432, "PublishedPort": 0, "Protocol": "tcp"}]}`)
+	case "ps -q web":
+		fmt.Println("5e6f7a\n3f4e5a\n8b9c0d")
+	case "ps -q db":
+		fmt.Println("1a2b3c")
+	case "inspect -f {{.State.Running}} 1a2b3c 5e6f7a 3f4e5a 8b9c0d":
+		fmt.Println("true\nfalse\ntrue\ntrue")
+	case "ps -q cache", "up -d --scale web=3 web":
+	default:
+		fmt.Fprint(os.Stderr, "ERROR: No containers to restart")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
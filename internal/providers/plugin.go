@@ -0,0 +1,344 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+// PLUGIN_PREFIX is the prefix of plugin executables that are found on the PATH,
+// dredge-provider-jira provides the jira provider.
+const PLUGIN_PREFIX = "dredge-provider-"
+
+// PLUGIN_NO_RESULT is the error code a plugin returns when it has no result
+// for a command.
+const PLUGIN_NO_RESULT = 1
+
+const pluginErrorCode = -32000
+
+// PluginProvider is a provider implemented by an external executable. Every
+// call starts the executable and exchanges JSON-RPC 2.0 messages, one per line,
+// over stdin and stdout:
+//
+//	host -> plugin:   {"jsonrpc":"2.0","id":1,"method":"execute_command","params":{"command":"get","config":{...}}}
+//	plugin -> host:   {"jsonrpc":"2.0","id":2,"method":"request_input","params":{"inputs":[{"name":"title"}]}}
+//	host -> plugin:   {"jsonrpc":"2.0","id":2,"result":{"title":"..."}}
+//	plugin -> host:   {"jsonrpc":"2.0","id":1,"result":[...]}
+//
+// The methods called on the plugin are name, discover, init and
+// execute_command. While handling a call the plugin can call log, confirm,
+// request_input, open_url, template, get_env, set_env and
+// add_provider_to_dredgefile on the host.
+type PluginProvider struct {
+	Path   string
+	name   string
+	config map[string]string
+}
+
+func NewPluginProvider(name, path string) *PluginProvider {
+	return &PluginProvider{
+		Path: path,
+		name: name,
+	}
+}
+
+// FindPlugins returns the plugins with the PLUGIN_PREFIX on the PATH.
+func FindPlugins() []*PluginProvider {
+	var plugins []*PluginProvider
+	found := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		matches, err := filepath.Glob(filepath.Join(dir, PLUGIN_PREFIX+"*"))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			name := strings.TrimPrefix(filepath.Base(match), PLUGIN_PREFIX)
+			if found[name] || !isExecutable(match) {
+				continue
+			}
+			found[name] = true
+			plugins = append(plugins, NewPluginProvider(name, match))
+		}
+	}
+	return plugins
+}
+
+// FindPlugin looks up the executable for the plugin with the provided name on
+// the PATH.
+func FindPlugin(name string) (*PluginProvider, error) {
+	path, err := exec.LookPath(PLUGIN_PREFIX + name)
+	if err != nil {
+		return nil, err
+	}
+	return NewPluginProvider(name, path), nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+func (p *PluginProvider) Name() string {
+	var name string
+	if err := p.call("name", nil, &name, nil); err != nil || name == "" {
+		return p.name
+	}
+	return name
+}
+
+func (p *PluginProvider) Discover(callbacks api.Callbacks) error {
+	return p.call("discover", nil, nil, callbacks)
+}
+
+func (p *PluginProvider) Init(config map[string]string) error {
+	if err := p.call("init", map[string]interface{}{"config": config}, nil, nil); err != nil {
+		return err
+	}
+	p.config = config
+	return nil
+}
+
+func (p *PluginProvider) ExecuteCommand(commandName string, c api.Callbacks) (interface{}, error) {
+	var result interface{}
+	err := p.call("execute_command", map[string]interface{}{
+		"command": commandName,
+		"config":  p.config,
+	}, &result, c)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type pluginMessage struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      int             `json:"id"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *pluginError    `json:"error,omitempty"`
+}
+
+type pluginError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type pluginInput struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Type              string   `json:"type"`
+	Values            []string `json:"values"`
+	DefaultValue      string   `json:"default_value"`
+	Min               *int     `json:"min"`
+	Max               *int     `json:"max"`
+	Required          bool     `json:"required"`
+	Pattern           string   `json:"pattern"`
+	MinLength         int      `json:"min_length"`
+	MaxLength         int      `json:"max_length"`
+	ValidationMessage string   `json:"validation_message"`
+}
+
+func (p *PluginProvider) call(method string, params interface{}, result interface{}, callbacks api.Callbacks) error {
+	cmd := exec.Command(p.Path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %v", p.name, err)
+	}
+
+	err = p.exchange(method, params, result, callbacks, stdin, stdout)
+	stdin.Close()
+	if err != nil {
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if err != nil {
+		return err
+	}
+	if waitErr != nil {
+		return fmt.Errorf("plugin %s failed: %v %s", p.name, waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (p *PluginProvider) exchange(method string, params interface{}, result interface{}, callbacks api.Callbacks, in io.Writer, out io.Reader) error {
+	encoder := json.NewEncoder(in)
+	request, err := newPluginMessage(1, method, params)
+	if err != nil {
+		return err
+	}
+	if err := encoder.Encode(request); err != nil {
+		return fmt.Errorf("failed to send %s to plugin %s: %v", method, p.name, err)
+	}
+
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg pluginMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return fmt.Errorf("invalid message from plugin %s: %v", p.name, err)
+		}
+
+		if msg.Method != "" {
+			if err := encoder.Encode(p.handleCallback(msg, callbacks)); err != nil {
+				return fmt.Errorf("failed to send response to plugin %s: %v", p.name, err)
+			}
+			continue
+		}
+
+		if msg.Id != request.Id {
+			return fmt.Errorf("unexpected response with id %d from plugin %s", msg.Id, p.name)
+		}
+		if msg.Error != nil {
+			if msg.Error.Code == PLUGIN_NO_RESULT {
+				return &api.NoResult{}
+			}
+			return fmt.Errorf("%s", msg.Error.Message)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("invalid result from plugin %s: %v", p.name, err)
+			}
+		}
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("plugin %s exited without a response to %s", p.name, method)
+}
+
+func newPluginMessage(id int, method string, params interface{}) (*pluginMessage, error) {
+	msg := &pluginMessage{
+		JsonRpc: "2.0",
+		Id:      id,
+		Method:  method,
+	}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		msg.Params = b
+	}
+	return msg, nil
+}
+
+func (p *PluginProvider) handleCallback(msg pluginMessage, callbacks api.Callbacks) *pluginMessage {
+	response := &pluginMessage{
+		JsonRpc: "2.0",
+		Id:      msg.Id,
+	}
+	if callbacks == nil {
+		response.Error = &pluginError{Code: pluginErrorCode, Message: fmt.Sprintf("%s is not available during this call", msg.Method)}
+		return response
+	}
+	result, err := p.executeCallback(msg.Method, msg.Params, callbacks)
+	if err != nil {
+		response.Error = &pluginError{Code: pluginErrorCode, Message: err.Error()}
+		return response
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		response.Error = &pluginError{Code: pluginErrorCode, Message: err.Error()}
+		return response
+	}
+	response.Result = b
+	return response
+}
+
+func (p *PluginProvider) executeCallback(method string, rawParams json.RawMessage, c api.Callbacks) (interface{}, error) {
+	var params struct {
+		Level    string            `json:"level"`
+		Msg      string            `json:"msg"`
+		Inputs   []pluginInput     `json:"inputs"`
+		Url      string            `json:"url"`
+		Input    string            `json:"input"`
+		Name     string            `json:"name"`
+		Value    interface{}       `json:"value"`
+		Resource string            `json:"resource"`
+		Provider string            `json:"provider"`
+		Config   map[string]string `json:"config"`
+	}
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, fmt.Errorf("invalid params for %s: %v", method, err)
+		}
+	}
+
+	switch method {
+	case "log":
+		level, err := toLogLevel(params.Level)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Log(level, "%s", params.Msg)
+	case "confirm":
+		return c.Confirm("%s", params.Msg)
+	case "request_input":
+		var requests []api.InputRequest
+		for _, i := range params.Inputs {
+			requests = append(requests, api.NewInputRequest(config.Input{
+				Name:              i.Name,
+				Description:       i.Description,
+				Type:              i.Type,
+				Values:            i.Values,
+				DefaultValue:      i.DefaultValue,
+				Min:               i.Min,
+				Max:               i.Max,
+				Required:          i.Required,
+				Pattern:           i.Pattern,
+				MinLength:         i.MinLength,
+				MaxLength:         i.MaxLength,
+				ValidationMessage: i.ValidationMessage,
+			}))
+		}
+		return c.RequestInput(requests)
+	case "open_url":
+		return nil, c.OpenUrl(params.Url)
+	case "template":
+		return c.Template(params.Input)
+	case "get_env":
+		value, ok := c.GetEnv(params.Name)
+		return map[string]interface{}{"value": value, "found": ok}, nil
+	case "set_env":
+		return nil, c.SetEnv(params.Name, params.Value)
+	case "add_provider_to_dredgefile":
+		return nil, c.AddProviderToDredgefile(params.Resource, params.Provider, params.Config)
+	}
+	return nil, fmt.Errorf("unknown method %s", method)
+}
+
+func toLogLevel(level string) (api.LogLevel, error) {
+	if level == "" {
+		return api.Info, nil
+	}
+	for l := api.Fatal; l <= api.Trace; l++ {
+		if strings.EqualFold(l.String(), level) {
+			return l, nil
+		}
+	}
+	return api.Info, fmt.Errorf("unknown log level %s", level)
+}
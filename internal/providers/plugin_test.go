@@ -0,0 +1,168 @@
+package providers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+type callbacksMock struct {
+	api.Callbacks
+	env    map[string]interface{}
+	logs   []string
+	inputs []api.InputRequest
+	values map[string]string
+}
+
+func (c *callbacksMock) Log(level api.LogLevel, msg string, args ...interface{}) error {
+	c.logs = append(c.logs, fmt.Sprintf("%s "+msg, append([]interface{}{level}, args...)...))
+	return nil
+}
+
+func (c *callbacksMock) RequestInput(inputRequests []api.InputRequest) (map[string]string, error) {
+	c.inputs = append(c.inputs, inputRequests...)
+	if c.values != nil {
+		return c.values, nil
+	}
+	return map[string]string{"state": "open"}, nil
+}
+
+func (c *callbacksMock) GetEnv(name string) (interface{}, bool) {
+	value, ok := c.env[name]
+	return value, ok
+}
+
+func (c *callbacksMock) Scope(vars map[string]interface{}) api.Callbacks {
+	env := make(map[string]interface{})
+	for key, value := range c.env {
+		env[key] = value
+	}
+	for key, value := range vars {
+		env[key] = value
+	}
+	return &callbacksMock{env: env}
+}
+
+func (c *callbacksMock) Template(input string) (string, error) {
+	t, err := template.New("").Parse(input)
+	if err != nil {
+		return "", err
+	}
+	out := new(strings.Builder)
+	if err := t.Execute(out, c.env); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func TestPluginProvider(t *testing.T) {
+	path, _ := writeHelperScript(t, PLUGIN_PREFIX+"helper", "TestPluginHelperProcess")
+	p := NewPluginProvider("helper", path)
+
+	assert.Equal(t, "helper-plugin", p.Name())
+
+	err := p.Init(map[string]string{})
+	assert.Equal(t, "could not find field url in config", fmt.Sprint(err))
+
+	err = p.Init(map[string]string{"url": "example.com"})
+	assert.Nil(t, err)
+
+	c := &callbacksMock{}
+	output, err := p.ExecuteCommand("get", c)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "1", "state": "open", "url": "example.com"},
+	}, output)
+	assert.Equal(t, []string{"INFO Getting open issues"}, c.logs)
+	assert.Equal(t, 1, len(c.inputs))
+	assert.Equal(t, "state", c.inputs[0].Name)
+	assert.Equal(t, api.Select, c.inputs[0].Type)
+	assert.Equal(t, []string{"open", "closed"}, c.inputs[0].Values)
+
+	_, err = p.ExecuteCommand("describe", c)
+	assert.Equal(t, &api.NoResult{}, err)
+
+	_, err = p.ExecuteCommand("crash", c)
+	assert.Equal(t, "plugin helper exited without a response to execute_command", fmt.Sprint(err))
+}
+
+func TestFindPlugins(t *testing.T) {
+	path, _ := writeHelperScript(t, PLUGIN_PREFIX+"helper", "TestPluginHelperProcess")
+	oldPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", oldPath)
+	os.Setenv("PATH", filepath.Dir(path))
+
+	plugins := FindPlugins()
+	assert.Equal(t, 1, len(plugins))
+	assert.Equal(t, path, plugins[0].Path)
+
+	plugin, err := FindPlugin("helper")
+	assert.Nil(t, err)
+	assert.Equal(t, path, plugin.Path)
+}
+
+// TestPluginHelperProcess is started by the plugin executable created in the
+// tests and implements a small plugin.
+func TestPluginHelperProcess(t *testing.T) {
+	if _, ok := helperArgs(); !ok {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	call := func(id int, method string, params interface{}) json.RawMessage {
+		msg, _ := newPluginMessage(id, method, params)
+		encoder.Encode(msg)
+		scanner.Scan()
+		var response pluginMessage
+		json.Unmarshal(scanner.Bytes(), &response)
+		return response.Result
+	}
+	respond := func(id int, result interface{}, err *pluginError) {
+		b, _ := json.Marshal(result)
+		encoder.Encode(&pluginMessage{JsonRpc: "2.0", Id: id, Result: b, Error: err})
+		os.Exit(0)
+	}
+
+	scanner.Scan()
+	var request pluginMessage
+	json.Unmarshal(scanner.Bytes(), &request)
+	var params struct {
+		Command string            `json:"command"`
+		Config  map[string]string `json:"config"`
+	}
+	json.Unmarshal(request.Params, &params)
+
+	switch request.Method {
+	case "name":
+		respond(request.Id, "helper-plugin", nil)
+	case "init":
+		if _, ok := params.Config["url"]; !ok {
+			respond(request.Id, nil, &pluginError{Code: pluginErrorCode, Message: "could not find field url in config"})
+		}
+		respond(request.Id, nil, nil)
+	case "execute_command":
+		switch params.Command {
+		case "get":
+			call(2, "log", map[string]string{"level": "info", "msg": "Getting open issues"})
+			var inputs map[string]string
+			json.Unmarshal(call(3, "request_input", map[string]interface{}{
+				"inputs": []pluginInput{{Name: "state", Type: "select", Values: []string{"open", "closed"}}},
+			}), &inputs)
+			respond(request.Id, []map[string]string{
+				{"name": "1", "state": inputs["state"], "url": params.Config["url"]},
+			}, nil)
+		case "describe":
+			respond(request.Id, nil, &pluginError{Code: PLUGIN_NO_RESULT, Message: "no result"})
+		}
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,245 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	REST_PAGE_SIZE = 100
+	// REST_MAX_RATE_LIMIT_WAIT is the longest time a request waits for the
+	// rate limit to reset, requests fail when the reset is further away.
+	REST_MAX_RATE_LIMIT_WAIT = time.Minute
+	restMaxAttempts          = 3
+)
+
+var LINK_NEXT_RE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+var GIT_REMOTE_RE = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::[0-9]+)?[:/](.+?)(?:\.git)?/?$`)
+
+// RestClient calls a JSON REST API that paginates with Link headers, like the
+// GitHub and GitLab APIs. Header is added to every request.
+type RestClient struct {
+	Service      string
+	Url          string
+	Header       http.Header
+	Client       *http.Client
+	MaxRateLimit time.Duration
+}
+
+func NewRestClient(service, url string, header http.Header) RestClient {
+	return RestClient{
+		Service:      service,
+		Url:          strings.TrimSuffix(url, "/"),
+		Header:       header,
+		Client:       http.DefaultClient,
+		MaxRateLimit: REST_MAX_RATE_LIMIT_WAIT,
+	}
+}
+
+type ApiError struct {
+	Service    string
+	StatusCode int
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("%s API error (%d): %s", e.Service, e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(*ApiError)
+	return ok && aerr.StatusCode == http.StatusNotFound
+}
+
+// Get decodes the response of a GET request into out.
+func (c *RestClient) Get(path string, out interface{}) error {
+	_, err := c.Do(http.MethodGet, c.Url+path, nil, out)
+	return err
+}
+
+// GetAll follows the pagination links and returns the items of all pages.
+func (c *RestClient) GetAll(path string, query url.Values) ([]json.RawMessage, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(REST_PAGE_SIZE))
+	next := c.Url + path + "?" + query.Encode()
+
+	var items []json.RawMessage
+	for next != "" {
+		var page []json.RawMessage
+		resp, err := c.Do(http.MethodGet, next, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		next = ""
+		if p := LINK_NEXT_RE.FindStringSubmatch(resp.Header.Get("Link")); len(p) > 1 {
+			next = p[1]
+		}
+	}
+	return items, nil
+}
+
+// Send sends body as json with the method to the path and decodes the
+// response into out.
+func (c *RestClient) Send(method, path string, body interface{}, out interface{}) error {
+	_, err := c.Do(method, c.Url+path, body, out)
+	return err
+}
+
+func (c *RestClient) Do(method, u string, body interface{}, out interface{}) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		var reader io.Reader
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, u, reader)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range c.Header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if wait, limited := rateLimitWait(resp); limited {
+			if attempt >= restMaxAttempts || wait > c.MaxRateLimit {
+				return nil, fmt.Errorf("%s API rate limit exceeded, try again in %s", c.Service, wait.Round(time.Second))
+			}
+			time.Sleep(wait)
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, &ApiError{Service: c.Service, StatusCode: resp.StatusCode, Message: errorMessage(resp.StatusCode, respBody)}
+		}
+
+		if out != nil && len(respBody) > 0 {
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return nil, fmt.Errorf("invalid response from %s API: %v", c.Service, err)
+			}
+		}
+		return resp, nil
+	}
+}
+
+// errorMessage returns the message or error field of an error response. The
+// message can also be an object, for example with validation errors. Jira
+// returns a list of errorMessages and an object with the errors per field,
+// GitHub returns a list of errors next to the message.
+func errorMessage(statusCode int, body []byte) string {
+	var msg struct {
+		Message       json.RawMessage
+		Error         string
+		ErrorMessages []string
+		Errors        json.RawMessage
+	}
+	if json.Unmarshal(body, &msg) == nil {
+		var s string
+		if json.Unmarshal(msg.Message, &s) == nil && s != "" {
+			return s
+		}
+		if len(msg.Message) > 0 && string(msg.Message) != "null" {
+			return string(msg.Message)
+		}
+		if msg.Error != "" {
+			return msg.Error
+		}
+		messages := msg.ErrorMessages
+		var errors map[string]string
+		json.Unmarshal(msg.Errors, &errors)
+		var fields []string
+		for field := range errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			messages = append(messages, fmt.Sprintf("%s: %s", field, errors[field]))
+		}
+		if len(messages) > 0 {
+			return strings.Join(messages, ", ")
+		}
+	}
+	return http.StatusText(statusCode)
+}
+
+// rateLimitWait checks if the request was rate limited and returns how long to
+// wait before retrying.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if resp.Header.Get(prefix+"Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get(prefix+"Reset"), 10, 64)
+			if err != nil {
+				return 0, true
+			}
+			wait := time.Until(time.Unix(reset, 0))
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, true
+		}
+	}
+	return 0, resp.StatusCode == http.StatusTooManyRequests
+}
+
+// parseGitRemote returns the host and the repository path of a git remote.
+func parseGitRemote(remote string) (string, string, error) {
+	p := GIT_REMOTE_RE.FindStringSubmatch(remote)
+	if len(p) < 3 || !strings.Contains(p[2], "/") {
+		return "", "", fmt.Errorf("could not parse git remote %s", remote)
+	}
+	return p[1], p[2], nil
+}
+
+func getOriginRemote() (string, error) {
+	output, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// newStubServer starts a stub REST API that records the requests and calls
+// handler to write the response.
+func newStubServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *[]stubRequest) {
+	var requests []stubRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := stubRequest{Method: r.Method, Path: r.URL.RequestURI()}
+		if b, _ := ioutil.ReadAll(r.Body); len(b) > 0 {
+			json.Unmarshal(b, &request.Body)
+		}
+		requests = append(requests, request)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRestErrorMessage(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		body       string
+		errMsg     string
+	}{
+		"message": {
+			statusCode: http.StatusNotFound,
+			body:       `{"message": "Not Found"}`,
+			errMsg:     "GitHub API error (404): Not Found",
+		},
+		"github validation errors": {
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `{"message": "Validation Failed", "errors": [{"resource": "Issue", "code": "missing_field", "field": "title"}]}`,
+			errMsg:     "GitHub API error (422): Validation Failed",
+		},
+		"jira errors": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"errorMessages": [], "errors": {"summary": "Summary is required"}}`,
+			errMsg:     "GitHub API error (400): summary: Summary is required",
+		},
+		"no message": {
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `{"errors": [{"code": "invalid"}]}`,
+			errMsg:     "GitHub API error (422): Unprocessable Entity",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		server, _ := newStubServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.statusCode)
+			fmt.Fprint(w, test.body)
+		})
+		c := NewRestClient("GitHub", server.URL, nil)
+		err := c.Send(http.MethodPost, "/repos/org/repo/issues", map[string]string{}, nil)
+		assert.Equal(t, test.errMsg, fmt.Sprint(err))
+	}
+}
@@ -0,0 +1,220 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const (
+	SHELL_FORMAT_JSON  = "json"
+	SHELL_FORMAT_LINES = "lines"
+)
+
+// ShellProvider maps the commands of a resource to shell commands. The config
+// contains a templated shell command per command name (get, describe, create,
+// update, search, ...) and optionally:
+//
+//	format:        json (default) or lines
+//	delimiter:     the delimiter between the fields of a line (default: tab)
+//	fields:        comma separated names of the fields in a line
+//	<cmd>_inputs:  comma separated inputs to request before running <cmd>
+//
+// The inputs of the command and the requested inputs are available in the
+// template, quoted for the shell, and as DRG_<NAME> environment variables.
+// Other values in the template, like the variables of the Dredgefile, are
+// inserted as they are. Whether the output is a list follows from the output
+// type of the command in the resource definition.
+type ShellProvider struct {
+	Commands   map[string]string
+	Inputs     map[string][]string
+	Format     string
+	Delimiter  string
+	Fields     []string
+	Definition *api.ResourceDefinition
+}
+
+func (s *ShellProvider) Name() string {
+	return "shell"
+}
+
+func (s *ShellProvider) Discover(callbacks api.Callbacks) error {
+	return nil
+}
+
+func (s *ShellProvider) SetResourceDefinition(rd *api.ResourceDefinition) {
+	s.Definition = rd
+}
+
+func (s *ShellProvider) Init(conf map[string]string) error {
+	s.Commands = make(map[string]string)
+	s.Inputs = make(map[string][]string)
+	s.Format = SHELL_FORMAT_JSON
+	s.Delimiter = "\t"
+	s.Fields = nil
+
+	for key, value := range conf {
+		switch key {
+		case "format":
+			s.Format = value
+		case "delimiter":
+			s.Delimiter = value
+		case "fields":
+			s.Fields = config.SplitList(value)
+		default:
+			if strings.HasSuffix(key, "_inputs") {
+				s.Inputs[strings.TrimSuffix(key, "_inputs")] = config.SplitList(value)
+			} else {
+				s.Commands[key] = value
+			}
+		}
+	}
+
+	if s.Format != SHELL_FORMAT_JSON && s.Format != SHELL_FORMAT_LINES {
+		return fmt.Errorf("unknown format %s (valid options are: %s, %s)", s.Format, SHELL_FORMAT_JSON, SHELL_FORMAT_LINES)
+	}
+	if s.Format == SHELL_FORMAT_LINES && len(s.Fields) == 0 {
+		return fmt.Errorf("fields are required for the %s format", SHELL_FORMAT_LINES)
+	}
+	if s.Delimiter == "" {
+		return fmt.Errorf("delimiter cannot be empty")
+	}
+	for command := range s.Inputs {
+		if _, ok := s.Commands[command]; !ok {
+			return fmt.Errorf("inputs defined for unknown command %s", command)
+		}
+	}
+	return nil
+}
+
+func (s *ShellProvider) ExecuteCommand(commandName string, c api.Callbacks) (interface{}, error) {
+	command, ok := s.Commands[commandName]
+	if !ok {
+		return nil, fmt.Errorf("could not find command %s", commandName)
+	}
+
+	definition := s.getCommandDefinition(commandName)
+	inputs := make(map[string]string)
+	if definition != nil {
+		for _, input := range definition.Inputs {
+			if value, ok := c.GetEnv(input.Name); ok {
+				inputs[input.Name] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	var requests []api.InputRequest
+	for _, name := range s.Inputs[commandName] {
+		requests = append(requests, api.InputRequest{
+			Name: name,
+			Type: api.Text,
+		})
+	}
+	if len(requests) > 0 {
+		requested, err := c.RequestInput(requests)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range requested {
+			inputs[name] = value
+		}
+	}
+
+	vars := make(map[string]interface{})
+	env := os.Environ()
+	for name, value := range inputs {
+		vars[name] = shellQuote(value)
+		env = append(env, fmt.Sprintf("%s=%s", shellEnvName(name), value))
+	}
+	command, err := c.Scope(vars).Template(command)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("/bin/bash", "-c", command)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		if eerr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s failed: %v %s", commandName, err, strings.TrimSpace(string(eerr.Stderr)))
+		}
+		return nil, err
+	}
+
+	list := definition != nil && strings.HasPrefix(definition.OutputType, "[]")
+	if s.Format == SHELL_FORMAT_LINES {
+		return s.parseLines(string(output), list)
+	}
+	return parseJson(output, list)
+}
+
+func (s *ShellProvider) getCommandDefinition(commandName string) *api.Command {
+	if s.Definition == nil {
+		return nil
+	}
+	for i := range s.Definition.Commands {
+		if s.Definition.Commands[i].Name == commandName {
+			return &s.Definition.Commands[i]
+		}
+	}
+	return nil
+}
+
+// shellQuote quotes a value, so it is a single word in the shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func shellEnvName(name string) string {
+	return "DRG_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+func (s *ShellProvider) parseLines(output string, list bool) (interface{}, error) {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		values := strings.SplitN(line, s.Delimiter, len(s.Fields))
+		item := make(map[string]interface{})
+		for i, field := range s.Fields {
+			if i < len(values) {
+				item[field] = strings.TrimSpace(values[i])
+			}
+		}
+		result = append(result, item)
+	}
+	if list {
+		return result, nil
+	}
+	if len(result) == 0 {
+		return nil, &api.NoResult{}
+	}
+	return result[0], nil
+}
+
+func parseJson(output []byte, list bool) (interface{}, error) {
+	if strings.TrimSpace(string(output)) == "" {
+		if list {
+			return []map[string]interface{}{}, nil
+		}
+		return nil, &api.NoResult{}
+	}
+	if list {
+		result := []map[string]interface{}{}
+		if err := json.Unmarshal(output, &result); err != nil {
+			return nil, fmt.Errorf("expected a json array of objects: %v", err)
+		}
+		return result, nil
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("expected a json object: %v", err)
+	}
+	return result, nil
+}
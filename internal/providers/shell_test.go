@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellProviderInit(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]string
+		errMsg string
+	}{
+		"json": {
+			config: map[string]string{"get": "cat incidents.json"},
+		},
+		"lines": {
+			config: map[string]string{"get": "cat incidents.txt", "format": "lines", "fields": "name,title"},
+		},
+		"unknown format": {
+			config: map[string]string{"get": "cat incidents.xml", "format": "xml"},
+			errMsg: "unknown format xml (valid options are: json, lines)",
+		},
+		"lines without fields": {
+			config: map[string]string{"get": "cat incidents.txt", "format": "lines"},
+			errMsg: "fields are required for the lines format",
+		},
+		"inputs for unknown command": {
+			config: map[string]string{"get": "cat incidents.json", "create_inputs": "title"},
+			errMsg: "inputs defined for unknown command create",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		err := (&ShellProvider{}).Init(test.config)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestShellProviderExecuteCommand(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]string
+		command string
+		env     map[string]interface{}
+		values  map[string]string
+		output  interface{}
+		errMsg  string
+	}{
+		"json list": {
+			config:  map[string]string{"get": `echo '[{"name": "1", "title": "Outage"}]'`},
+			command: "get",
+			output:  []map[string]interface{}{{"name": "1", "title": "Outage"}},
+		},
+		"json object with template": {
+			config:  map[string]string{"describe": `printf '{"name": "%s"}' {{ .name }}`},
+			command: "describe",
+			env:     map[string]interface{}{"name": "42"},
+			output:  map[string]interface{}{"name": "42"},
+		},
+		"empty json list": {
+			config:  map[string]string{"search": "true"},
+			command: "search",
+			output:  []map[string]interface{}{},
+		},
+		"invalid json": {
+			config:  map[string]string{"get": "echo '{}'"},
+			command: "get",
+			errMsg:  "expected a json array of objects: json: cannot unmarshal object into Go value of type []map[string]interface {}",
+		},
+		"lines": {
+			config:  map[string]string{"get": "printf '1;Outage;open\\n2;Slow;closed\\n'", "format": "lines", "delimiter": ";", "fields": "name,title,state"},
+			command: "get",
+			output: []map[string]interface{}{
+				{"name": "1", "title": "Outage", "state": "open"},
+				{"name": "2", "title": "Slow", "state": "closed"},
+			},
+		},
+		"lines object": {
+			config:  map[string]string{"create": "printf '3\\tNew'", "format": "lines", "fields": "name,title"},
+			command: "create",
+			output:  map[string]interface{}{"name": "3", "title": "New"},
+		},
+		"inputs as env vars and template": {
+			config:  map[string]string{"create": `printf '{"title": "%s", "severity": "%s"}' "$DRG_TITLE" {{ .severity }}`, "create_inputs": "title,severity"},
+			command: "create",
+			values:  map[string]string{"title": "Outage", "severity": "high"},
+			output:  map[string]interface{}{"title": "Outage", "severity": "high"},
+		},
+		"inputs are quoted in the template": {
+			config:  map[string]string{"describe": `printf '{"name": "%s"}' {{ .name }}`},
+			command: "describe",
+			env:     map[string]interface{}{"name": "1'; echo injected; echo '"},
+			output:  map[string]interface{}{"name": "1'; echo injected; echo '"},
+		},
+		"custom command with list output": {
+			config:  map[string]string{"open": `echo '[{"name": "1"}]'`},
+			command: "open",
+			output:  []map[string]interface{}{{"name": "1"}},
+		},
+		"unknown command": {
+			config:  map[string]string{"get": "true"},
+			command: "update",
+			errMsg:  "could not find command update",
+		},
+		"failing command": {
+			config:  map[string]string{"get": "echo broken >&2; exit 3"},
+			command: "get",
+			errMsg:  "get failed: exit status 3 broken",
+		},
+		"no result": {
+			config:  map[string]string{"describe": "true"},
+			command: "describe",
+			errMsg:  (&api.NoResult{}).Error(),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		s := &ShellProvider{}
+		err := s.Init(test.config)
+		s.SetResourceDefinition(&api.ResourceDefinition{
+			Name: "incident",
+			Commands: []api.Command{
+				{Name: "get", OutputType: "[]incident"},
+				{Name: "search", OutputType: "[]incident"},
+				{Name: "describe", Inputs: []api.InputRequest{{Name: "name"}}, OutputType: "incident"},
+				{Name: "create", OutputType: "incident"},
+				{Name: "open", OutputType: "[]incident"},
+			},
+		})
+		assert.Nil(t, err)
+		output, err := s.ExecuteCommand(test.command, &callbacksMock{env: test.env, values: test.values})
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.output, output)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
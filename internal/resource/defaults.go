@@ -26,19 +26,43 @@ func GetDefaultResourceDefinitions() []api.ResourceDefinition {
 			Commands: []api.Command{
 				{
 					Name:       "get",
-					Inputs:     []string{},
 					OutputType: "[]release",
 				},
 				{
-					Name:       "search",
-					Inputs:     []string{"text"},
+					Name: "search",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "text",
+							Description: "Search text",
+							Type:        api.Text,
+						},
+					},
 					OutputType: "[]release",
 				},
 				{
-					Name:       "describe",
-					Inputs:     []string{"name"},
+					Name: "describe",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Release name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
 					OutputType: "object",
 				},
+				{
+					Name: "delete",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Release name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
+					OutputType: "string",
+				},
 			},
 		},
 		{
@@ -73,16 +97,200 @@ func GetDefaultResourceDefinitions() []api.ResourceDefinition {
 			Commands: []api.Command{
 				{
 					Name:       "get",
-					Inputs:     []string{},
 					OutputType: "[]issue",
 				},
 				{
-					Name:       "create",
-					Inputs:     []string{},
+					Name: "search",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "text",
+							Description: "Search text",
+							Type:        api.Text,
+						},
+					},
+					OutputType: "[]issue",
+				},
+				{
+					Name: "describe",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Issue name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
+					OutputType: "object",
+				},
+				{
+					Name: "create",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "title",
+							Description: "Issue title",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:         "type",
+							Description:  "Issue type, one of the types configured for the provider",
+							Type:         api.Text,
+							DefaultValue: "bug",
+						},
+						{
+							Name:        "description",
+							Description: "Issue description",
+							Type:        api.Text,
+						},
+					},
+					OutputType: "issue",
+				},
+				{
+					Name: "update",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Issue name",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:        "state",
+							Description: "Issue state (open or closed)",
+							Type:        api.Text,
+							Pattern:     "^(open|closed)$",
+						},
+						{
+							Name:        "labels",
+							Description: "Comma separated labels",
+							Type:        api.Text,
+						},
+						{
+							Name:        "assignees",
+							Description: "Comma separated assignees",
+							Type:        api.Text,
+						},
+					},
+					OutputType: "issue",
+				},
+				{
+					Name: "comment",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Issue name",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:        "body",
+							Description: "Comment",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
+					OutputType: "string",
+				},
+				{
+					Name: "close",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Issue name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
 					OutputType: "issue",
 				},
 			},
 		},
+		{
+			Name: "merge-request",
+			Fields: []api.Field{
+				{
+					Name:        "name",
+					Description: "Merge request number",
+					Type:        "string",
+				},
+				{
+					Name:        "title",
+					Description: "Merge request title",
+					Type:        "string",
+				},
+				{
+					Name:        "state",
+					Description: "Merge request state",
+					Type:        "string",
+				},
+				{
+					Name:        "source",
+					Description: "Source branch",
+					Type:        "string",
+				},
+				{
+					Name:        "target",
+					Description: "Target branch",
+					Type:        "string",
+				},
+				{
+					Name:        "author",
+					Description: "Author",
+					Type:        "string",
+				},
+				{
+					Name:        "date",
+					Description: "Merge request creation date",
+					Type:        "date",
+				},
+			},
+			Commands: []api.Command{
+				{
+					Name:       "get",
+					OutputType: "[]merge-request",
+				},
+				{
+					Name: "describe",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Merge request number",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
+					OutputType: "object",
+				},
+				{
+					Name: "create",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "title",
+							Description: "Merge request title",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:        "source",
+							Description: "Source branch",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:        "target",
+							Description: "Target branch (default: the default branch)",
+							Type:        api.Text,
+						},
+						{
+							Name:        "description",
+							Description: "Merge request description",
+							Type:        api.Text,
+						},
+					},
+					OutputType: "merge-request",
+				},
+			},
+		},
 		{
 			Name: "doc",
 			Fields: []api.Field{
@@ -110,12 +318,17 @@ func GetDefaultResourceDefinitions() []api.ResourceDefinition {
 			Commands: []api.Command{
 				{
 					Name:       "get",
-					Inputs:     []string{},
 					OutputType: "[]doc",
 				},
 				{
-					Name:       "search",
-					Inputs:     []string{},
+					Name: "search",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "text",
+							Description: "Search text",
+							Type:        api.Text,
+						},
+					},
 					OutputType: "[]doc",
 				},
 			},
@@ -147,17 +360,64 @@ func GetDefaultResourceDefinitions() []api.ResourceDefinition {
 			Commands: []api.Command{
 				{
 					Name:       "get",
-					Inputs:     []string{},
 					OutputType: "[]deploy",
 				},
 				{
-					Name:       "describe",
-					Inputs:     []string{},
+					Name: "describe",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
 					OutputType: "object",
 				},
 				{
-					Name:       "update",
-					Inputs:     []string{},
+					Name: "update",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Name",
+							Type:        api.Text,
+							Required:    true,
+						},
+						{
+							Name:        "version",
+							Description: "Version",
+							Type:        api.Text,
+						},
+						{
+							Name:        "instances",
+							Description: "Number of instances",
+							Type:        api.Number,
+						},
+					},
+					OutputType: "deploy",
+				},
+				{
+					Name: "logs",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
+					OutputType: "string",
+				},
+				{
+					Name: "restart",
+					Inputs: []api.InputRequest{
+						{
+							Name:        "name",
+							Description: "Name",
+							Type:        api.Text,
+							Required:    true,
+						},
+					},
 					OutputType: "deploy",
 				},
 			},
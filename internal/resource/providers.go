@@ -2,28 +2,49 @@ package resource
 
 import (
 	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/dredge-dev/dredge/internal/config"
 	"github.com/dredge-dev/dredge/internal/providers"
 )
 
 var PROVIDERS = map[string]Provider{
-	"github-releases":      &providers.GithubReleasesProvider{},
-	"github-issues":        &providers.GithubIssuesProvider{},
-	"local-doc":            &providers.LocalDocProvider{},
-	"local-docker-compose": &providers.LocalDockerComposeProvider{},
+	"github-releases":       &providers.GithubReleasesProvider{},
+	"github-issues":         &providers.GithubIssuesProvider{},
+	"gitlab-releases":       &providers.GitlabReleasesProvider{},
+	"gitlab-issues":         &providers.GitlabIssuesProvider{},
+	"gitlab-merge-requests": &providers.GitlabMergeRequestsProvider{},
+	"jira":                  &providers.JiraProvider{},
+	"kubernetes":            &providers.KubernetesProvider{},
+	"local-doc":             &providers.LocalDocProvider{},
+	"local-docker-compose":  &providers.LocalDockerComposeProvider{},
+	"shell":                 &providers.ShellProvider{},
 }
 
 func GetProviders() ([]Provider, error) {
-	var providers []Provider
+	var result []Provider
 	for _, provider := range PROVIDERS {
-		providers = append(providers, provider)
+		result = append(result, provider)
 	}
-	return providers, nil
+	for _, plugin := range providers.FindPlugins() {
+		name := strings.TrimPrefix(filepath.Base(plugin.Path), providers.PLUGIN_PREFIX)
+		if _, ok := PROVIDERS[name]; !ok {
+			result = append(result, plugin)
+		}
+	}
+	return result, nil
 }
 
 func CreateProvider(conf config.ResourceProvider) (Provider, error) {
-	p, err := getProvider(conf.Provider)
+	var p Provider
+	var err error
+	if conf.Plugin != "" {
+		p = providers.NewPluginProvider(conf.Provider, conf.Plugin)
+	} else {
+		p, err = getProvider(conf.Provider)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +57,16 @@ func CreateProvider(conf config.ResourceProvider) (Provider, error) {
 
 func getProvider(name string) (Provider, error) {
 	if provider, ok := PROVIDERS[name]; ok {
-		return provider, nil
+		return newProvider(provider), nil
+	}
+	if plugin, err := providers.FindPlugin(name); err == nil {
+		return plugin, nil
 	}
 	return nil, fmt.Errorf("could not find provider %s", name)
 }
+
+// newProvider creates a new instance of a provider, so resources that use the
+// same provider don't share their config.
+func newProvider(p Provider) Provider {
+	return reflect.New(reflect.TypeOf(p).Elem()).Interface().(Provider)
+}
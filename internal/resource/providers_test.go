@@ -7,7 +7,8 @@ import (
 )
 
 func TestAddInput(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
 	providers, err := GetProviders()
 	assert.Nil(t, err)
-	assert.Equal(t, 4, len(providers))
+	assert.Equal(t, len(PROVIDERS), len(providers))
 }
@@ -0,0 +1,293 @@
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dredge-dev/dredge/internal/api"
+)
+
+const (
+	OP_EQUAL         = "="
+	OP_NOT_EQUAL     = "!="
+	OP_MATCH         = "~="
+	OP_LESS          = "<"
+	OP_LESS_EQUAL    = "<="
+	OP_GREATER       = ">"
+	OP_GREATER_EQUAL = ">="
+)
+
+// Operators are ordered so the two character operators are matched first.
+var OPERATORS = []string{OP_NOT_EQUAL, OP_MATCH, OP_LESS_EQUAL, OP_GREATER_EQUAL, OP_EQUAL, OP_LESS, OP_GREATER}
+
+var DATE_LAYOUTS = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// Query filters, sorts, limits and selects the fields of the output of a
+// resource command.
+type Query struct {
+	Filters []Filter
+	SortBy  string
+	Limit   int
+	Columns []string
+}
+
+type Filter struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+// NewQuery creates a query from filters in the format <field><operator><value>,
+// a sort field (prefixed with - to sort descending), a limit (0 for no limit)
+// and a list of columns.
+func NewQuery(filters []string, sortBy string, limit int, columns []string) (*Query, error) {
+	q := &Query{
+		SortBy:  sortBy,
+		Limit:   limit,
+		Columns: columns,
+	}
+	for _, f := range filters {
+		filter, err := ParseFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		q.Filters = append(q.Filters, *filter)
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("limit cannot be negative")
+	}
+	return q, nil
+}
+
+func ParseFilter(filter string) (*Filter, error) {
+	index := strings.IndexAny(filter, "!~<>=")
+	if index <= 0 {
+		return nil, fmt.Errorf("invalid filter %s (expected <field><operator><value>)", filter)
+	}
+	for _, op := range OPERATORS {
+		if strings.HasPrefix(filter[index:], op) {
+			return &Filter{
+				Field:    filter[:index],
+				Operator: op,
+				Value:    filter[index+len(op):],
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operator in filter %s (valid operators are: %s)", filter, strings.Join(OPERATORS, ", "))
+}
+
+// Apply runs the query on the output. The output has to be an array of a
+// resource type.
+func (q *Query) Apply(output *api.CommandOutput) error {
+	if !output.Type.IsArray || len(output.Type.Fields) == 0 {
+		return fmt.Errorf("filter, sort, limit and columns are only supported for lists of resources")
+	}
+
+	s := reflect.ValueOf(output.Output)
+	if s.Kind() != reflect.Slice {
+		return fmt.Errorf("expected array type but provider returned object")
+	}
+	var items []interface{}
+	for i := 0; i < s.Len(); i++ {
+		items = append(items, s.Index(i).Interface())
+	}
+
+	items, err := q.filter(output.Type, items)
+	if err != nil {
+		return err
+	}
+	if err := q.sort(output.Type, items); err != nil {
+		return err
+	}
+	if q.Limit > 0 && len(items) > q.Limit {
+		items = items[:q.Limit]
+	}
+
+	fields, err := q.selectColumns(output.Type)
+	if err != nil {
+		return err
+	}
+
+	output.Type = &api.Type{
+		Name:    output.Type.Name,
+		IsArray: true,
+		Fields:  fields,
+	}
+	output.Output = items
+	return nil
+}
+
+func (q *Query) filter(t *api.Type, items []interface{}) ([]interface{}, error) {
+	if len(q.Filters) == 0 {
+		return items, nil
+	}
+	var matchers []func(interface{}) bool
+	for _, f := range q.Filters {
+		m, err := f.matcher(t)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	var filtered []interface{}
+	for _, item := range items {
+		matches := true
+		for _, m := range matchers {
+			if !m(item) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+func (f Filter) matcher(t *api.Type) (func(interface{}) bool, error) {
+	field, err := getField(t, f.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f.Operator {
+	case OP_EQUAL:
+		return func(item interface{}) bool {
+			return getString(item, field.Name) == f.Value
+		}, nil
+	case OP_NOT_EQUAL:
+		return func(item interface{}) bool {
+			return getString(item, field.Name) != f.Value
+		}, nil
+	case OP_MATCH:
+		re, err := regexp.Compile(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in filter for %s: %v", f.Field, err)
+		}
+		return func(item interface{}) bool {
+			return re.MatchString(getString(item, field.Name))
+		}, nil
+	}
+
+	if field.Type != "date" {
+		return nil, fmt.Errorf("operator %s can only be used for date fields, %s is of type %s", f.Operator, field.Name, field.Type)
+	}
+	value, err := parseDate(f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %s in filter for %s", f.Value, f.Field)
+	}
+	return func(item interface{}) bool {
+		d, err := parseDate(getValue(item, field.Name))
+		if err != nil {
+			return false
+		}
+		switch f.Operator {
+		case OP_LESS:
+			return d.Before(value)
+		case OP_LESS_EQUAL:
+			return !d.After(value)
+		case OP_GREATER:
+			return d.After(value)
+		default:
+			return !d.Before(value)
+		}
+	}, nil
+}
+
+func (q *Query) sort(t *api.Type, items []interface{}) error {
+	if q.SortBy == "" {
+		return nil
+	}
+	name := strings.TrimPrefix(q.SortBy, "-")
+	descending := name != q.SortBy
+	field, err := getField(t, name)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if descending {
+			a, b = b, a
+		}
+		return less(field, a, b)
+	})
+	return nil
+}
+
+func less(field *api.Field, a, b interface{}) bool {
+	if field.Type == "date" {
+		da, errA := parseDate(getValue(a, field.Name))
+		db, errB := parseDate(getValue(b, field.Name))
+		if errA == nil && errB == nil {
+			return da.Before(db)
+		}
+	}
+	return getString(a, field.Name) < getString(b, field.Name)
+}
+
+func (q *Query) selectColumns(t *api.Type) ([]api.Field, error) {
+	if len(q.Columns) == 0 {
+		return t.Fields, nil
+	}
+	var fields []api.Field
+	for _, c := range q.Columns {
+		field, err := getField(t, c)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, *field)
+	}
+	return fields, nil
+}
+
+func getField(t *api.Type, name string) (*api.Field, error) {
+	var names []string
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return &f, nil
+		}
+		names = append(names, f.Name)
+	}
+	return nil, fmt.Errorf("unknown field %s for %s (valid fields are: %s)", name, t.Name, strings.Join(names, ", "))
+}
+
+func getValue(item interface{}, name string) interface{} {
+	s := reflect.ValueOf(item)
+	if s.Kind() != reflect.Map {
+		return nil
+	}
+	val := s.MapIndex(reflect.ValueOf(name))
+	if !val.IsValid() {
+		return nil
+	}
+	return val.Interface()
+}
+
+func getString(item interface{}, name string) string {
+	value := getValue(item, name)
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func parseDate(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range DATE_LAYOUTS {
+			if d, err := time.Parse(layout, v); err == nil {
+				return d, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse date %v", value)
+}
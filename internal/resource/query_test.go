@@ -0,0 +1,169 @@
+package resource
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := map[string]struct {
+		filter string
+		result *Filter
+		errMsg string
+	}{
+		"equal": {
+			filter: "state=open",
+			result: &Filter{Field: "state", Operator: "=", Value: "open"},
+		},
+		"not equal": {
+			filter: "state!=closed",
+			result: &Filter{Field: "state", Operator: "!=", Value: "closed"},
+		},
+		"match": {
+			filter: "title~=^fix",
+			result: &Filter{Field: "title", Operator: "~=", Value: "^fix"},
+		},
+		"greater or equal": {
+			filter: "date>=2022-01-01",
+			result: &Filter{Field: "date", Operator: ">=", Value: "2022-01-01"},
+		},
+		"value with operator": {
+			filter: "title=a=b",
+			result: &Filter{Field: "title", Operator: "=", Value: "a=b"},
+		},
+		"no operator": {
+			filter: "state",
+			errMsg: "invalid filter state (expected <field><operator><value>)",
+		},
+		"no field": {
+			filter: "=open",
+			errMsg: "invalid filter =open (expected <field><operator><value>)",
+		},
+		"unknown operator": {
+			filter: "state~open",
+			errMsg: "invalid operator in filter state~open (valid operators are: !=, ~=, <=, >=, =, <, >)",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		result, err := ParseFilter(test.filter)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.result, result)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestApplyQuery(t *testing.T) {
+	issueType := &api.Type{
+		Name:    "issue",
+		IsArray: true,
+		Fields: []api.Field{
+			{Name: "name", Type: "string"},
+			{Name: "title", Type: "string"},
+			{Name: "state", Type: "string"},
+			{Name: "date", Type: "date"},
+		},
+	}
+	issues := []map[string]interface{}{
+		{"name": "1", "title": "Fix login", "state": "closed", "date": "2022-01-10T10:00:00Z"},
+		{"name": "2", "title": "Add search", "state": "open", "date": "2022-03-01T10:00:00Z"},
+		{"name": "3", "title": "Fix logout", "state": "open", "date": "2022-02-01T10:00:00Z"},
+	}
+
+	tests := map[string]struct {
+		filters []string
+		sortBy  string
+		limit   int
+		columns []string
+		names   []string
+		fields  []string
+		errMsg  string
+	}{
+		"no query": {
+			names:  []string{"1", "2", "3"},
+			fields: []string{"name", "title", "state", "date"},
+		},
+		"filter equal": {
+			filters: []string{"state=open"},
+			names:   []string{"2", "3"},
+		},
+		"multiple filters": {
+			filters: []string{"state!=closed", "title~=^Fix"},
+			names:   []string{"3"},
+		},
+		"filter date": {
+			filters: []string{"date>2022-01-31"},
+			names:   []string{"2", "3"},
+		},
+		"filter date with time": {
+			filters: []string{"date<=2022-02-01T10:00:00Z"},
+			names:   []string{"1", "3"},
+		},
+		"sort by date": {
+			sortBy: "date",
+			names:  []string{"1", "3", "2"},
+		},
+		"sort descending with limit": {
+			sortBy: "-date",
+			limit:  2,
+			names:  []string{"2", "3"},
+		},
+		"columns": {
+			columns: []string{"title", "name"},
+			names:   []string{"1", "2", "3"},
+			fields:  []string{"title", "name"},
+		},
+		"unknown field": {
+			filters: []string{"author=me"},
+			errMsg:  "unknown field author for issue (valid fields are: name, title, state, date)",
+		},
+		"date operator on string field": {
+			filters: []string{"state>open"},
+			errMsg:  "operator > can only be used for date fields, state is of type string",
+		},
+		"invalid date": {
+			filters: []string{"date>yesterday"},
+			errMsg:  "invalid date yesterday in filter for date",
+		},
+		"invalid regular expression": {
+			filters: []string{"title~=[a"},
+			errMsg:  "invalid regular expression in filter for title: error parsing regexp: missing closing ]: `[a`",
+		},
+		"unknown column": {
+			columns: []string{"author"},
+			errMsg:  "unknown field author for issue (valid fields are: name, title, state, date)",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		q, err := NewQuery(test.filters, test.sortBy, test.limit, test.columns)
+		assert.Nil(t, err)
+		output := &api.CommandOutput{Type: issueType, Output: issues}
+		err = q.Apply(output)
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			var names []string
+			for _, item := range output.Output.([]interface{}) {
+				names = append(names, item.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, test.names, names)
+			if test.fields != nil {
+				var fields []string
+				for _, f := range output.Type.Fields {
+					fields = append(fields, f.Name)
+				}
+				assert.Equal(t, test.fields, fields)
+			}
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
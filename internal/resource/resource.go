@@ -20,6 +20,12 @@ type Provider interface {
 	ExecuteCommand(commandName string, callbacks api.Callbacks) (interface{}, error)
 }
 
+// DefinitionProvider is a provider that needs the definition of the resource,
+// like the output types of the commands.
+type DefinitionProvider interface {
+	SetResourceDefinition(rd *api.ResourceDefinition)
+}
+
 func NewResource(rd *api.ResourceDefinition, r config.Resource) (*Resource, error) {
 	var providers []Provider
 	for _, p := range r {
@@ -27,6 +33,9 @@ func NewResource(rd *api.ResourceDefinition, r config.Resource) (*Resource, erro
 		if err != nil {
 			return nil, err
 		}
+		if dp, ok := provider.(DefinitionProvider); ok {
+			dp.SetResourceDefinition(rd)
+		}
 		providers = append(providers, provider)
 	}
 
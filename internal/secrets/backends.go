@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvBackend reads secrets from environment variables, name is the variable
+// (default: the name of the secret).
+type EnvBackend struct {
+	Variable string
+}
+
+func (b *EnvBackend) Name() string {
+	return "env"
+}
+
+func (b *EnvBackend) Init(config map[string]string) error {
+	b.Variable = config["name"]
+	return nil
+}
+
+func (b *EnvBackend) Get(name string) (string, error) {
+	variable := b.Variable
+	if variable == "" {
+		variable = name
+	}
+	value, ok := os.LookupEnv(variable)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", variable)
+	}
+	return value, nil
+}
+
+// FileBackend reads secrets from a file encrypted with drg secret encrypt. The
+// config contains the path of the file, the key_file (default:
+// ~/.dredge/secret.key or DRG_SECRET_KEY) and the key in the file (default:
+// the name of the secret).
+type FileBackend struct {
+	Path    string
+	KeyFile string
+	Key     string
+}
+
+var decryptedFiles = struct {
+	sync.Mutex
+	values map[string]map[string]string
+}{values: map[string]map[string]string{}}
+
+func (b *FileBackend) Name() string {
+	return "file"
+}
+
+func (b *FileBackend) Init(config map[string]string) error {
+	b.Path = config["path"]
+	if b.Path == "" {
+		return fmt.Errorf("could not find field path in config")
+	}
+	b.KeyFile = config["key_file"]
+	b.Key = config["key"]
+	return nil
+}
+
+func (b *FileBackend) Get(name string) (string, error) {
+	values, err := b.decrypt()
+	if err != nil {
+		return "", err
+	}
+	key := b.Key
+	if key == "" {
+		key = name
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("could not find %s in %s", key, b.Path)
+	}
+	return value, nil
+}
+
+// decrypt reads the values in the file, files are only decrypted once.
+func (b *FileBackend) decrypt() (map[string]string, error) {
+	decryptedFiles.Lock()
+	defer decryptedFiles.Unlock()
+	cacheKey := b.Path + "\x00" + b.KeyFile
+	if values, ok := decryptedFiles.values[cacheKey]; ok {
+		return values, nil
+	}
+	key, err := ReadKey(b.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ReadEncryptedFile(b.Path, key)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", b.Path, err)
+	}
+	decryptedFiles.values[cacheKey] = values
+	return values, nil
+}
+
+// CommandBackend runs cmd and uses its output as the value of the secret, so
+// secret managers can be used through their CLI. The name of the secret is
+// available in DRG_SECRET_NAME.
+type CommandBackend struct {
+	Cmd string
+}
+
+func (b *CommandBackend) Name() string {
+	return "command"
+}
+
+func (b *CommandBackend) Init(config map[string]string) error {
+	b.Cmd = config["cmd"]
+	if b.Cmd == "" {
+		return fmt.Errorf("could not find field cmd in config")
+	}
+	return nil
+}
+
+func (b *CommandBackend) Get(name string) (string, error) {
+	cmd := exec.Command("/bin/bash", "-c", b.Cmd)
+	cmd.Env = append(os.Environ(), "DRG_SECRET_NAME="+name)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v %s", b.Cmd, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -0,0 +1,162 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	KEY_SIZE         = 32
+	KEY_ENV          = "DRG_SECRET_KEY"
+	ENCRYPTED_HEADER = "DRG-AES256-GCM"
+)
+
+// DefaultKeyFile returns the path of the key that is used when no key_file is
+// configured.
+func DefaultKeyFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".dredge", "secret.key"), nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// ReadKey reads a base64 encoded key from keyFile. Without keyFile the key is
+// read from DRG_SECRET_KEY or the default key file.
+func ReadKey(keyFile string) ([]byte, error) {
+	var encoded string
+	if keyFile == "" && os.Getenv(KEY_ENV) != "" {
+		encoded = os.Getenv(KEY_ENV)
+	} else {
+		var err error
+		if keyFile == "" {
+			keyFile, err = DefaultKeyFile()
+		} else {
+			keyFile, err = expandHome(keyFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadFile(keyFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("could not find key file %s, create it with drg secret encrypt or set %s", keyFile, KEY_ENV)
+		} else if err != nil {
+			return nil, err
+		}
+		encoded = string(b)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != KEY_SIZE {
+		return nil, fmt.Errorf("invalid key (expected %d base64 encoded bytes)", KEY_SIZE)
+	}
+	return key, nil
+}
+
+// ReadOrCreateKey reads the key from keyFile and creates a new random key when
+// the file doesn't exist.
+func ReadOrCreateKey(keyFile string) ([]byte, error) {
+	if keyFile == "" && os.Getenv(KEY_ENV) != "" {
+		return ReadKey(keyFile)
+	}
+	var err error
+	if keyFile == "" {
+		keyFile, err = DefaultKeyFile()
+	} else {
+		keyFile, err = expandHome(keyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(keyFile); !errors.Is(err, os.ErrNotExist) {
+		return ReadKey(keyFile)
+	}
+	key := make([]byte, KEY_SIZE)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := ioutil.WriteFile(keyFile, []byte(encoded), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext with AES-256-GCM. The result is a header line
+// followed by the base64 encoded nonce and ciphertext.
+func Encrypt(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	return []byte(fmt.Sprintf("%s\n%s\n", ENCRYPTED_HEADER, base64.StdEncoding.EncodeToString(ciphertext))), nil
+}
+
+func Decrypt(content, key []byte) ([]byte, error) {
+	lines := strings.SplitN(strings.TrimSpace(string(content)), "\n", 2)
+	if len(lines) != 2 || strings.TrimSpace(lines[0]) != ENCRYPTED_HEADER {
+		return nil, fmt.Errorf("not encrypted with drg secret encrypt")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid ciphertext: too short")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt, wrong key or modified file")
+	}
+	return plaintext, nil
+}
+
+func ReadEncryptedFile(path string, key []byte) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := Decrypt(content, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
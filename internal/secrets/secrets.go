@@ -0,0 +1,119 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const DEFAULT_BACKEND = "env"
+
+// Backend resolves the values of secrets. The config of a backend is the
+// config of the secret in the Dredgefile.
+type Backend interface {
+	Name() string
+	Init(config map[string]string) error
+	Get(name string) (string, error)
+}
+
+var BACKENDS = map[string]func() Backend{
+	"env":     func() Backend { return &EnvBackend{} },
+	"file":    func() Backend { return &FileBackend{} },
+	"command": func() Backend { return &CommandBackend{} },
+}
+
+// Register adds a secret backend, so it can be used in the secrets section of
+// the Dredgefile.
+func Register(name string, backend func() Backend) {
+	BACKENDS[name] = backend
+}
+
+// Secret is a value in the environment that is only resolved when it is used.
+type Secret struct {
+	Name    string
+	backend Backend
+	once    sync.Once
+	value   string
+	err     error
+}
+
+func New(name string, s config.Secret) (*Secret, error) {
+	backendName := s.Backend
+	if backendName == "" {
+		backendName = DEFAULT_BACKEND
+	}
+	newBackend, ok := BACKENDS[backendName]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %s for secret %s", backendName, name)
+	}
+	backend := newBackend()
+	config := s.Config
+	if config == nil {
+		config = map[string]string{}
+	}
+	if err := backend.Init(config); err != nil {
+		return nil, fmt.Errorf("invalid config for secret %s: %v", name, err)
+	}
+	return &Secret{Name: name, backend: backend}, nil
+}
+
+// Value resolves the secret on first use, the value is masked in logs once it
+// is resolved.
+func (s *Secret) Value() (string, error) {
+	s.once.Do(func() {
+		s.value, s.err = s.backend.Get(s.Name)
+		if s.err != nil {
+			s.err = fmt.Errorf("could not resolve secret %s: %v", s.Name, s.err)
+		} else {
+			addMask(s.value)
+		}
+	})
+	return s.value, s.err
+}
+
+// String returns the value of the secret or an empty string when it can't be
+// resolved, it's used when the secret is printed in templates.
+func (s *Secret) String() string {
+	value, _ := s.Value()
+	return value
+}
+
+const MASK = "****"
+
+var masks = struct {
+	sync.RWMutex
+	values []string
+}{}
+
+func addMask(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	masks.Lock()
+	defer masks.Unlock()
+	for _, v := range masks.values {
+		if v == value {
+			return
+		}
+	}
+	masks.values = append(masks.values, value)
+	// Longer values first, so a secret containing another one is masked
+	// completely
+	sort.Slice(masks.values, func(i, j int) bool {
+		return len(masks.values[i]) > len(masks.values[j])
+	})
+}
+
+// Mask replaces the values of the resolved secrets in s.
+func Mask(s string) string {
+	masks.RLock()
+	defer masks.RUnlock()
+	for _, value := range masks.values {
+		s = strings.Replace(s, value, MASK, -1)
+	}
+	return s
+}
@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys", "secret.key")
+	key, err := ReadOrCreateKey(keyFile)
+	assert.Nil(t, err)
+	assert.Equal(t, KEY_SIZE, len(key))
+
+	read, err := ReadKey(keyFile)
+	assert.Nil(t, err)
+	assert.Equal(t, key, read)
+
+	encrypted, err := Encrypt([]byte("TOKEN: s3cr3t\n"), key)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(encrypted), "s3cr3t")
+
+	plaintext, err := Decrypt(encrypted, key)
+	assert.Nil(t, err)
+	assert.Equal(t, "TOKEN: s3cr3t\n", string(plaintext))
+
+	otherKey, err := ReadOrCreateKey(filepath.Join(t.TempDir(), "other.key"))
+	assert.Nil(t, err)
+	_, err = Decrypt(encrypted, otherKey)
+	assert.Equal(t, "could not decrypt, wrong key or modified file", fmt.Sprint(err))
+
+	_, err = Decrypt([]byte("TOKEN: s3cr3t\n"), key)
+	assert.Equal(t, "not encrypted with drg secret encrypt", fmt.Sprint(err))
+}
+
+func TestReadKey(t *testing.T) {
+	t.Setenv(KEY_ENV, "c2hvcnQ=")
+	_, err := ReadKey("")
+	assert.Equal(t, "invalid key (expected 32 base64 encoded bytes)", fmt.Sprint(err))
+
+	keyFile := filepath.Join(t.TempDir(), "missing.key")
+	_, err = ReadKey(keyFile)
+	assert.Equal(t, fmt.Sprintf("could not find key file %s, create it with drg secret encrypt or set DRG_SECRET_KEY", keyFile), fmt.Sprint(err))
+}
+
+func TestBackends(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "secret.key")
+	key, err := ReadOrCreateKey(keyFile)
+	assert.Nil(t, err)
+	encrypted, err := Encrypt([]byte("DB_PASSWORD: hunter2\napi: abc123\n"), key)
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "secrets.enc")
+	assert.Nil(t, ioutil.WriteFile(path, encrypted, 0644))
+
+	t.Setenv("DRG_TEST_SECRET", "from-env")
+
+	tests := map[string]struct {
+		name   string
+		secret config.Secret
+		value  string
+		errMsg string
+	}{
+		"env": {
+			name:   "DRG_TEST_SECRET",
+			secret: config.Secret{},
+			value:  "from-env",
+		},
+		"env with name": {
+			name:   "TOKEN",
+			secret: config.Secret{Backend: "env", Config: map[string]string{"name": "DRG_TEST_SECRET"}},
+			value:  "from-env",
+		},
+		"missing env": {
+			name:   "DRG_TEST_MISSING",
+			secret: config.Secret{},
+			errMsg: "could not resolve secret DRG_TEST_MISSING: environment variable DRG_TEST_MISSING is not set",
+		},
+		"file": {
+			name:   "DB_PASSWORD",
+			secret: config.Secret{Backend: "file", Config: map[string]string{"path": path, "key_file": keyFile}},
+			value:  "hunter2",
+		},
+		"file with key": {
+			name:   "API_KEY",
+			secret: config.Secret{Backend: "file", Config: map[string]string{"path": path, "key_file": keyFile, "key": "api"}},
+			value:  "abc123",
+		},
+		"missing key in file": {
+			name:   "OTHER",
+			secret: config.Secret{Backend: "file", Config: map[string]string{"path": path, "key_file": keyFile}},
+			errMsg: fmt.Sprintf("could not resolve secret OTHER: could not find OTHER in %s", path),
+		},
+		"command": {
+			name:   "TOKEN",
+			secret: config.Secret{Backend: "command", Config: map[string]string{"cmd": "echo token-for-$DRG_SECRET_NAME"}},
+			value:  "token-for-TOKEN",
+		},
+		"failing command": {
+			name:   "TOKEN",
+			secret: config.Secret{Backend: "command", Config: map[string]string{"cmd": "echo denied >&2; exit 3"}},
+			errMsg: "could not resolve secret TOKEN: echo denied >&2; exit 3 failed: exit status 3 denied",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		secret, err := New(test.name, test.secret)
+		assert.Nil(t, err)
+		value, err := secret.Value()
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.value, value)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("TOKEN", config.Secret{Backend: "vault"})
+	assert.Equal(t, "unknown backend vault for secret TOKEN", fmt.Sprint(err))
+
+	_, err = New("TOKEN", config.Secret{Backend: "file"})
+	assert.Equal(t, "invalid config for secret TOKEN: could not find field path in config", fmt.Sprint(err))
+
+	Register("static", func() Backend { return &staticBackend{} })
+	secret, err := New("TOKEN", config.Secret{Backend: "static"})
+	assert.Nil(t, err)
+	assert.Equal(t, "static-TOKEN", secret.String())
+}
+
+func TestMask(t *testing.T) {
+	t.Setenv("DRG_TEST_MASK", "p4ssw0rd")
+	t.Setenv("DRG_TEST_MASK_LONG", "p4ssw0rd-and-more")
+
+	assert.Equal(t, "login with p4ssw0rd", Mask("login with p4ssw0rd"))
+
+	for _, name := range []string{"DRG_TEST_MASK", "DRG_TEST_MASK_LONG"} {
+		secret, err := New(name, config.Secret{})
+		assert.Nil(t, err)
+		_, err = secret.Value()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, "login with **** or ****", Mask("login with p4ssw0rd or p4ssw0rd-and-more"))
+}
+
+type staticBackend struct{}
+
+func (b *staticBackend) Name() string {
+	return "static"
+}
+
+func (b *staticBackend) Init(config map[string]string) error {
+	return nil
+}
+
+func (b *staticBackend) Get(name string) (string, error) {
+	return "static-" + name, nil
+}
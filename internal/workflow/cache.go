@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+const hashesDir = "hashes"
+
+// stepCache stores the hashes of the sources and outputs of a shell step, so
+// the step can be skipped when nothing changed since the last run.
+type stepCache struct {
+	path    string
+	inputs  string
+	outputs []string
+}
+
+type stepHashes struct {
+	Inputs  string
+	Outputs string
+}
+
+func (workflow *Workflow) newStepCache(shell *config.ShellStep, runtime *Runtime) (*stepCache, error) {
+	command, err := runtime.GetCommand(false, shell.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := workflow.templateGlobs(shell.Sources)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := workflow.templateGlobs(shell.Outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := sha256.New()
+	fmt.Fprintf(inputs, "%s\x00", command)
+	for _, envVar := range runtime.envVars {
+		fmt.Fprintf(inputs, "%s\x00", envVar)
+	}
+	if err := hashFiles(inputs, sources); err != nil {
+		return nil, err
+	}
+
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", workflow.key(), shell.Runtime, shell.Cmd)))
+	return &stepCache{
+		path:    filepath.Join(dredgeDir, hashesDir, hex.EncodeToString(key[:])),
+		inputs:  hex.EncodeToString(inputs.Sum(nil)),
+		outputs: outputs,
+	}, nil
+}
+
+func (workflow *Workflow) templateGlobs(globs []string) ([]string, error) {
+	var templated []string
+	for _, glob := range globs {
+		t, err := workflow.Callbacks.Template(glob)
+		if err != nil {
+			return nil, err
+		}
+		if workflow.Dir != "" && !filepath.IsAbs(t) {
+			t = filepath.Join(workflow.Dir, t)
+		}
+		templated = append(templated, t)
+	}
+	return templated, nil
+}
+
+// UpToDate returns true when the inputs didn't change since the last run and
+// the outputs are still the ones produced by that run.
+func (c *stepCache) UpToDate() (bool, error) {
+	content, err := ioutil.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var previous stepHashes
+	if err := json.Unmarshal(content, &previous); err != nil {
+		return false, nil
+	}
+	if previous.Inputs != c.inputs {
+		return false, nil
+	}
+	outputs, err := c.hashOutputs()
+	if err != nil {
+		return false, err
+	}
+	return outputs != "" && previous.Outputs == outputs, nil
+}
+
+func (c *stepCache) Save() error {
+	outputs, err := c.hashOutputs()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(stepHashes{Inputs: c.inputs, Outputs: outputs})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, content, 0644)
+}
+
+// hashOutputs returns an empty string when one of the outputs doesn't exist.
+func (c *stepCache) hashOutputs() (string, error) {
+	hash := sha256.New()
+	for _, output := range c.outputs {
+		files, err := expandGlob(output)
+		if err != nil {
+			return "", err
+		}
+		if len(files) == 0 {
+			return "", nil
+		}
+	}
+	if err := hashFiles(hash, c.outputs); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashFiles(w io.Writer, globs []string) error {
+	var files []string
+	for _, glob := range globs {
+		matches, err := expandGlob(glob)
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	previous := ""
+	for _, file := range files {
+		if file == previous {
+			continue
+		}
+		previous = file
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(content)
+		fmt.Fprintf(w, "%s\x00%x\x00", file, hash)
+	}
+	return nil
+}
+
+// expandGlob returns the files matching the glob. Directories are expanded to
+// all files they contain and ** matches any number of directories.
+func expandGlob(glob string) ([]string, error) {
+	var matches []string
+	if strings.Contains(glob, "**") {
+		parts := strings.SplitN(glob, "**", 2)
+		root := strings.TrimSuffix(parts[0], "/")
+		if root == "" {
+			root = "."
+		}
+		pattern := strings.TrimPrefix(parts[1], "/")
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) && path == root {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				if info.Name() == dredgeDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if pattern == "" {
+				matches = append(matches, path)
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			ok, err := matchSuffix(pattern, rel)
+			if err != nil {
+				return err
+			}
+			if ok {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+		return matches, err
+	}
+
+	paths, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				matches = append(matches, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// matchSuffix matches the pattern against the last path elements of path.
+func matchSuffix(pattern, path string) (bool, error) {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(filepath.ToSlash(path), "/")
+	if len(pathParts) < len(patternParts) {
+		return false, nil
+	}
+	suffix := strings.Join(pathParts[len(pathParts)-len(patternParts):], "/")
+	return filepath.Match(pattern, suffix)
+}
+
+// CleanCache removes the hashes of all shell steps, so every step runs again.
+func CleanCache() error {
+	return os.RemoveAll(filepath.Join(dredgeDir, hashesDir))
+}
@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func inTempDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "drg-cache")
+	assert.Nil(t, err)
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(dir))
+	return func() {
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestExecuteShellStepWithSources(t *testing.T) {
+	defer inTempDir(t)()
+
+	assert.Nil(t, os.MkdirAll("src/pkg", 0755))
+	assert.Nil(t, ioutil.WriteFile("src/pkg/main.go", []byte("package main"), 0644))
+
+	skipped := 0
+	c := &CallbacksMock{
+		MLog: func(level api.LogLevel, msg string, args ...interface{}) error {
+			skipped++
+			return nil
+		},
+	}
+	workflow := &Workflow{
+		ID:   "./Dredgefile:build",
+		Name: "build",
+		Steps: []config.Step{
+			{
+				Shell: &config.ShellStep{
+					Cmd:     "cat src/pkg/main.go > out && echo run >> runs",
+					Sources: []string{"src/**/*.go"},
+					Outputs: []string{"out"},
+				},
+			},
+		},
+		Callbacks: c,
+	}
+	runs := func() int {
+		content, _ := ioutil.ReadFile("runs")
+		return strings.Count(string(content), "run")
+	}
+
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 1, runs())
+
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 1, runs())
+	assert.Equal(t, 1, skipped)
+
+	assert.Nil(t, ioutil.WriteFile("src/pkg/main.go", []byte("package changed"), 0644))
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 2, runs())
+
+	assert.Nil(t, os.Remove("out"))
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 3, runs())
+
+	workflow.Force = true
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 4, runs())
+
+	workflow.Force = false
+	assert.Nil(t, CleanCache())
+	assert.Nil(t, workflow.Execute())
+	assert.Equal(t, 5, runs())
+}
+
+func TestExpandGlob(t *testing.T) {
+	defer inTempDir(t)()
+
+	for _, f := range []string{"a.go", "dir/b.go", "dir/sub/c.go", "dir/sub/d.txt", ".dredge/hashes/e.go"} {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(f), 0755))
+		assert.Nil(t, ioutil.WriteFile(f, []byte(f), 0644))
+	}
+
+	tests := map[string]struct {
+		glob  string
+		files []string
+	}{
+		"single file": {
+			glob:  "a.go",
+			files: []string{"a.go"},
+		},
+		"pattern": {
+			glob:  "*.go",
+			files: []string{"a.go"},
+		},
+		"directory": {
+			glob:  "dir/sub",
+			files: []string{"dir/sub/c.go", "dir/sub/d.txt"},
+		},
+		"recursive": {
+			glob:  "**/*.go",
+			files: []string{"a.go", "dir/b.go", "dir/sub/c.go"},
+		},
+		"recursive in directory": {
+			glob:  "dir/**",
+			files: []string{"dir/b.go", "dir/sub/c.go", "dir/sub/d.txt"},
+		},
+		"no match": {
+			glob:  "missing/**/*.go",
+			files: nil,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		files, err := expandGlob(test.glob)
+		assert.Nil(t, err)
+		sort.Strings(files)
+		assert.Equal(t, test.files, files)
+	}
+}
@@ -0,0 +1,148 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+func (workflow *Workflow) executeForeachStep(foreach *config.ForeachStep) error {
+	items, err := workflow.getForeachItems(foreach.Items)
+	if err != nil {
+		return err
+	}
+	if foreach.Parallel > 1 {
+		return workflow.executeForeachParallel(foreach, items)
+	}
+	for i := range items {
+		branch := workflow.foreachBranch(foreach, items, i)
+		if err := branch.executeSteps(foreach.Steps); err != nil {
+			return err
+		}
+		stop, err := branch.shouldBreak(foreach.BreakIf)
+		if err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+	}
+	return nil
+}
+
+// foreachBranch returns a copy of the workflow that runs an item in its own
+// scope, so the loop variables and the variables set by the steps of an item
+// are not visible after the item.
+func (workflow *Workflow) foreachBranch(foreach *config.ForeachStep, items []interface{}, i int) Workflow {
+	branch := *workflow
+	branch.Callbacks = workflow.Callbacks.Scope(map[string]interface{}{
+		foreach.GetAs():    items[i],
+		foreach.GetIndex(): i,
+	})
+	return branch
+}
+
+// executeForeachParallel runs the items at the same time, every item has its
+// own scope, so the loop variables don't overwrite each other.
+func (workflow *Workflow) executeForeachParallel(foreach *config.ForeachStep, items []interface{}) error {
+	lock := &sync.Mutex{}
+	errs := runConcurrently(len(items), foreach.Parallel, true, func(i int) error {
+		prefix := fmt.Sprintf("[%s %d] ", foreach.GetAs(), i)
+		stdout := newPrefixWriter(prefix, workflow.getStdout(), lock)
+		stderr := newPrefixWriter(prefix, workflow.getStderr(), lock)
+
+		branch := workflow.foreachBranch(foreach, items, i)
+		branch.stdout = stdout
+		branch.stderr = stderr
+		err := branch.executeSteps(foreach.Steps)
+
+		if flushErr := stdout.Flush(); err == nil {
+			err = flushErr
+		}
+		if flushErr := stderr.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s %d: %v", foreach.GetAs(), i, err)
+		}
+		stop, err := branch.shouldBreak(foreach.BreakIf)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return errStop
+		}
+		return nil
+	})
+	return combineErrors(errs)
+}
+
+func (workflow *Workflow) shouldBreak(breakIf string) (bool, error) {
+	if breakIf == "" {
+		return false, nil
+	}
+	cond, err := workflow.Callbacks.Template(breakIf)
+	if err != nil {
+		return false, err
+	}
+	return config.IsTrue(cond), nil
+}
+
+// getForeachItems resolves items either as the name of a variable in the
+// environment or as a template that renders a JSON array or one item per line.
+func (workflow *Workflow) getForeachItems(items string) ([]interface{}, error) {
+	if !strings.Contains(items, "{{") {
+		name := strings.TrimSpace(items)
+		value, ok := workflow.Callbacks.GetEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("could not find variable %s for foreach", name)
+		}
+		return toItems(value)
+	}
+	templated, err := workflow.Callbacks.Template(items)
+	if err != nil {
+		return nil, err
+	}
+	return toItems(templated)
+}
+
+func toItems(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok {
+		return stringToItems(s)
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot loop over value of type %T in foreach", value)
+	}
+	items := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+func stringToItems(s string) ([]interface{}, error) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "[") {
+		var items []interface{}
+		if err := json.Unmarshal([]byte(trimmed), &items); err != nil {
+			return nil, fmt.Errorf("could not parse items for foreach: %v", err)
+		}
+		return items, nil
+	}
+	var items []interface{}
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items, nil
+}
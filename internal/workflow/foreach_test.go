@@ -0,0 +1,211 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dredge-dev/dredge/internal/api"
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteForeachStep(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]interface{}
+		foreach  config.ForeachStep
+		message  string
+		messages []string
+		errMsg   string
+	}{
+		"registered variable": {
+			env: map[string]interface{}{
+				"issues": []map[string]interface{}{
+					{"name": "1", "title": "first"},
+					{"name": "2", "title": "second"},
+				},
+			},
+			foreach: config.ForeachStep{
+				Items: "issues",
+			},
+			message:  "{{ .index }} {{ .item.name }} {{ .item.title }}",
+			messages: []string{"0 1 first", "1 2 second"},
+		},
+		"custom loop variables": {
+			env: map[string]interface{}{
+				"versions": []string{"1.0", "2.0"},
+			},
+			foreach: config.ForeachStep{
+				Items: "versions",
+				As:    "version",
+				Index: "i",
+			},
+			message:  "{{ .i }} {{ .version }}",
+			messages: []string{"0 1.0", "1 2.0"},
+		},
+		"template with lines": {
+			env: map[string]interface{}{
+				"services": "api\nweb\n",
+			},
+			foreach: config.ForeachStep{
+				Items: "{{ .services }}",
+			},
+			message:  "{{ .index }} {{ .item }}",
+			messages: []string{"0 api", "1 web"},
+		},
+		"template with json": {
+			foreach: config.ForeachStep{
+				Items: `["a", "b", "c"]{{ "" }}`,
+			},
+			message:  "{{ .index }} {{ .item }}",
+			messages: []string{"0 a", "1 b", "2 c"},
+		},
+		"break if": {
+			env: map[string]interface{}{
+				"versions": []string{"1.0", "2.0", "3.0"},
+			},
+			foreach: config.ForeachStep{
+				Items:   "versions",
+				BreakIf: `{{ if eq .item "2.0" }}true{{ end }}`,
+			},
+			message:  "{{ .index }} {{ .item }}",
+			messages: []string{"0 1.0", "1 2.0"},
+		},
+		"unknown variable": {
+			foreach: config.ForeachStep{
+				Items: "unknown",
+			},
+			errMsg: "could not find variable unknown for foreach",
+		},
+		"not a list": {
+			env: map[string]interface{}{
+				"count": 3,
+			},
+			foreach: config.ForeachStep{
+				Items: "count",
+			},
+			errMsg: "cannot loop over value of type int in foreach",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		var messages []string
+		c := &CallbacksMock{
+			Env: test.env,
+			MLog: func(level api.LogLevel, msg string, args ...interface{}) error {
+				messages = append(messages, fmt.Sprintf(msg, args...))
+				return nil
+			},
+		}
+		foreach := test.foreach
+		foreach.Steps = []config.Step{
+			{
+				Log: &config.LogStep{
+					Level:   config.LOG_INFO,
+					Message: test.message,
+				},
+			},
+		}
+		workflow := &Workflow{
+			Name:      "workflow",
+			Steps:     []config.Step{{Foreach: &foreach}},
+			Callbacks: c,
+		}
+
+		err := workflow.Execute()
+		if test.errMsg == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, test.messages, messages)
+		} else {
+			assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestExecuteForeachStepParallel(t *testing.T) {
+	var lock sync.Mutex
+	var messages []string
+	c := &CallbacksMock{
+		Env: map[string]interface{}{
+			"versions": []string{"1.0", "2.0", "3.0"},
+		},
+		MLog: func(level api.LogLevel, msg string, args ...interface{}) error {
+			lock.Lock()
+			defer lock.Unlock()
+			messages = append(messages, fmt.Sprintf(msg, args...))
+			return nil
+		},
+	}
+	workflow := &Workflow{
+		Name: "workflow",
+		Steps: []config.Step{
+			{
+				Foreach: &config.ForeachStep{
+					Items:    "versions",
+					As:       "version",
+					Parallel: 2,
+					Steps: []config.Step{
+						{Shell: &config.ShellStep{Cmd: "echo {{ .version }}"}},
+						{Log: &config.LogStep{Level: config.LOG_INFO, Message: "{{ .index }}: {{ .version }}"}},
+					},
+				},
+			},
+		},
+		Callbacks: c,
+		stdout:    new(bytes.Buffer),
+	}
+
+	err := workflow.Execute()
+	assert.Nil(t, err)
+	sort.Strings(messages)
+	assert.Equal(t, []string{"0: 1.0", "1: 2.0", "2: 3.0"}, messages)
+	_, ok := c.Env["version"]
+	assert.False(t, ok)
+}
+
+func TestExecuteForeachStepScope(t *testing.T) {
+	for _, parallel := range []int{0, 2} {
+		t.Logf("Running test case parallel %d", parallel)
+		var lock sync.Mutex
+		var messages []string
+		c := &CallbacksMock{
+			Env: map[string]interface{}{
+				"versions": []string{"1.0", "2.0"},
+			},
+			MLog: func(level api.LogLevel, msg string, args ...interface{}) error {
+				lock.Lock()
+				defer lock.Unlock()
+				messages = append(messages, fmt.Sprintf(msg, args...))
+				return nil
+			},
+		}
+		workflow := &Workflow{
+			Name: "workflow",
+			Steps: []config.Step{
+				{
+					Foreach: &config.ForeachStep{
+						Items:    "versions",
+						Parallel: parallel,
+						Steps: []config.Step{
+							{Set: &config.SetStep{"last": "{{ .item }}"}},
+							{Log: &config.LogStep{Level: config.LOG_INFO, Message: "{{ .index }}: {{ .last }}"}},
+						},
+					},
+				},
+			},
+			Callbacks: c,
+		}
+
+		err := workflow.Execute()
+		assert.Nil(t, err)
+		sort.Strings(messages)
+		assert.Equal(t, []string{"0: 1.0", "1: 2.0"}, messages)
+		for _, name := range []string{"item", "index", "last"} {
+			_, ok := c.Env[name]
+			assert.False(t, ok, name)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package workflow
 
 import (
-	"strings"
-
 	"github.com/dredge-dev/dredge/internal/config"
 )
 
@@ -11,13 +9,8 @@ func (workflow *Workflow) executeIfStep(ifStep *config.IfStep) error {
 	if err != nil {
 		return err
 	}
-	if isTrue(cond) {
+	if config.IsTrue(cond) {
 		return workflow.executeSteps(ifStep.Steps)
 	}
 	return nil
 }
-
-func isTrue(s string) bool {
-	l := strings.ToLower(s)
-	return l == "1" || l == "t" || l == "true" || l == "yes"
-}
@@ -0,0 +1,154 @@
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dredge-dev/dredge/internal/config"
+)
+
+// errStop stops the scheduling of new branches without being reported as a failure
+var errStop = errors.New("stop")
+
+func (workflow *Workflow) executeParallelStep(parallel *config.ParallelStep) error {
+	lock := &sync.Mutex{}
+	errs := runConcurrently(len(parallel.Steps), parallel.Limit, parallel.FailFast, func(i int) error {
+		step := parallel.Steps[i]
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+		stdout := newPrefixWriter(fmt.Sprintf("[%s] ", name), workflow.getStdout(), lock)
+		stderr := newPrefixWriter(fmt.Sprintf("[%s] ", name), workflow.getStderr(), lock)
+
+		branch := *workflow
+		branch.stdout = stdout
+		branch.stderr = stderr
+		err := branch.executeStep(step)
+
+		if flushErr := stdout.Flush(); err == nil {
+			err = flushErr
+		}
+		if flushErr := stderr.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	})
+	return combineErrors(errs)
+}
+
+func (workflow *Workflow) getStdout() io.Writer {
+	if workflow.stdout == nil {
+		return os.Stdout
+	}
+	return workflow.stdout
+}
+
+func (workflow *Workflow) getStderr() io.Writer {
+	if workflow.stderr == nil {
+		return os.Stderr
+	}
+	return workflow.stderr
+}
+
+// runConcurrently calls fn for 0..n-1, running at most limit calls at the same
+// time (0 means no limit). When failFast is set, no new calls are started after
+// the first error. A call returning errStop always prevents new calls from starting.
+func runConcurrently(n, limit int, failFast bool, fn func(i int) error) []error {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	errs := make([]error, n)
+	slots := make(chan struct{}, limit)
+	var stopped int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+		if atomic.LoadInt32(&stopped) == 1 {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = fn(i)
+			if errs[i] == errStop || (errs[i] != nil && failFast) {
+				atomic.StoreInt32(&stopped, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func combineErrors(errs []error) error {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil && err != errStop {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// prefixWriter prefixes every line written to it. Writers sharing a lock don't
+// interleave their lines.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(prefix string, out io.Writer, lock *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		prefix: prefix,
+		out:    out,
+		lock:   lock,
+	}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(p.out, "%s%s", p.prefix, p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes the remaining partial line, if any.
+func (p *prefixWriter) Flush() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.buf) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(p.out, "%s%s\n", p.prefix, p.buf)
+	p.buf = nil
+	return err
+}
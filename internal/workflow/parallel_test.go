@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dredge-dev/dredge/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteParallelStep(t *testing.T) {
+	c := &CallbacksMock{}
+	workflow := &Workflow{
+		Name:        "workflow",
+		Description: "perform work",
+		Steps: []config.Step{
+			{
+				Parallel: &config.ParallelStep{
+					Steps: []config.Step{
+						{Shell: &config.ShellStep{Cmd: "echo lint", StdOut: "LINT"}},
+						{Shell: &config.ShellStep{Cmd: "echo test", StdOut: "TEST"}},
+						{Set: &config.SetStep{"image": "built"}},
+					},
+				},
+			},
+		},
+		Callbacks: c,
+	}
+
+	err := workflow.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, "lint\n", c.Env["LINT"])
+	assert.Equal(t, "test\n", c.Env["TEST"])
+	assert.Equal(t, "built", c.Env["image"])
+}
+
+func TestExecuteParallelStepPrefixesOutput(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	workflow := &Workflow{
+		Name: "workflow",
+		Steps: []config.Step{
+			{
+				Parallel: &config.ParallelStep{
+					Steps: []config.Step{
+						{Name: "lint", Shell: &config.ShellStep{Cmd: "echo first && echo second"}},
+					},
+				},
+			},
+		},
+		Callbacks: &CallbacksMock{},
+		stdout:    stdout,
+	}
+
+	err := workflow.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, "[lint] first\n[lint] second\n", stdout.String())
+}
+
+func TestExecuteParallelStepErrors(t *testing.T) {
+	tests := map[string]struct {
+		failFast bool
+		limit    int
+		executed []string
+		errMsg   string
+	}{
+		"wait for all": {
+			failFast: false,
+			limit:    1,
+			executed: []string{"1", "2", "3"},
+			errMsg:   "fails: exit status 1; 3: exit status 2",
+		},
+		"fail fast": {
+			failFast: true,
+			limit:    1,
+			executed: []string{"1"},
+			errMsg:   "fails: exit status 1",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("Running test case %s", testName)
+		var executed []string
+		c := &CallbacksMock{
+			MTemplate: func(input string) (string, error) {
+				executed = append(executed, input)
+				return input, nil
+			},
+		}
+		workflow := &Workflow{
+			Name: "workflow",
+			Steps: []config.Step{
+				{
+					Parallel: &config.ParallelStep{
+						Steps: []config.Step{
+							{Name: "fails", Shell: &config.ShellStep{Cmd: "exit 1", StdOut: "OUT"}},
+							{Shell: &config.ShellStep{Cmd: "exit 0", StdOut: "OUT"}},
+							{Shell: &config.ShellStep{Cmd: "exit 2", StdOut: "OUT"}},
+						},
+						Limit:    test.limit,
+						FailFast: test.failFast,
+					},
+				},
+			},
+			Callbacks: c,
+			stdout:    new(bytes.Buffer),
+			stderr:    new(bytes.Buffer),
+		}
+
+		err := workflow.Execute()
+		assert.Equal(t, test.errMsg, fmt.Sprint(err))
+		assert.Equal(t, len(test.executed), len(executed))
+	}
+}
+
+func TestRunConcurrentlyLimit(t *testing.T) {
+	var lock sync.Mutex
+	running := 0
+	maxRunning := 0
+	started := make(chan int, 10)
+	release := make(chan struct{})
+	done := make(chan []error)
+	go func() {
+		done <- runConcurrently(10, 3, false, func(i int) error {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			started <- i
+			<-release
+
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return nil
+		})
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	select {
+	case i := <-started:
+		t.Errorf("branch %d started while 3 branches were running", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	assert.Nil(t, combineErrors(<-done))
+	assert.Equal(t, 3, maxRunning)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := newPrefixWriter("[a] ", out, &sync.Mutex{})
+
+	fmt.Fprint(w, "hel")
+	fmt.Fprint(w, "lo\nwor")
+	fmt.Fprint(w, "ld")
+	assert.Nil(t, w.Flush())
+
+	assert.Equal(t, "[a] hello\n[a] world\n", out.String())
+}
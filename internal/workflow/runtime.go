@@ -1,7 +1,6 @@
 package workflow
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -15,11 +14,18 @@ import (
 const dredgeDir = ".dredge"
 const cacheDir = "cache"
 
+// containerEnvFile is the env file of containers, the environment variables
+// are written to a pipe on file descriptor 3, so they don't show up in the
+// process list or on disk.
+const containerEnvFile = "/dev/fd/3"
+
 type Templater func(input string) (string, error)
 
 type Runtime struct {
 	Config    config.Runtime
 	Templater Templater
+	Dir       string
+	envVars   []string
 }
 
 func (workflow *Workflow) GetRuntime(name string) (*Runtime, error) {
@@ -27,6 +33,7 @@ func (workflow *Workflow) GetRuntime(name string) (*Runtime, error) {
 		return &Runtime{
 			Config:    config.Runtime{Type: "native"},
 			Templater: workflow.Callbacks.Template,
+			Dir:       workflow.Dir,
 		}, nil
 	}
 	for _, r := range workflow.Runtimes {
@@ -34,19 +41,21 @@ func (workflow *Workflow) GetRuntime(name string) (*Runtime, error) {
 			return &Runtime{
 				Config:    r,
 				Templater: workflow.Callbacks.Template,
+				Dir:       workflow.Dir,
 			}, nil
 		}
 	}
 	return nil, fmt.Errorf("Runtime %s is not defined", name)
 }
 
-func (r *Runtime) Execute(interactive bool, command string, stdin io.Reader, stdout, stderr *bytes.Buffer) error {
+func (r *Runtime) Execute(interactive bool, command string, stdin io.Reader, stdout, stderr io.Writer) error {
 	cmd, err := r.GetCommand(interactive, command)
 	if err != nil {
 		return err
 	}
 	osCmd := exec.Command("/bin/bash", "-c", cmd)
 	osCmd.Env = os.Environ()
+	osCmd.Dir = r.Dir
 	if stdin != nil {
 		osCmd.Stdin = stdin
 	} else {
@@ -62,7 +71,25 @@ func (r *Runtime) Execute(interactive bool, command string, stdin io.Reader, std
 	} else {
 		osCmd.Stderr = os.Stderr
 	}
-	return osCmd.Run()
+	if len(r.envVars) == 0 {
+		return osCmd.Run()
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	osCmd.ExtraFiles = []*os.File{reader}
+	if err := osCmd.Start(); err != nil {
+		reader.Close()
+		writer.Close()
+		return err
+	}
+	reader.Close()
+	go func() {
+		io.WriteString(writer, strings.Join(r.envVars, "\n")+"\n")
+		writer.Close()
+	}()
+	return osCmd.Wait()
 }
 
 func (r *Runtime) GetCommand(interactive bool, cmd string) (string, error) {
@@ -84,22 +111,33 @@ func (r *Runtime) GetCommand(interactive bool, cmd string) (string, error) {
 func (r *Runtime) getContainerCommand(interactive bool, cmd string) (string, error) {
 	workDir := r.Config.GetHome()
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return "", err
+	currentDir := r.Dir
+	if currentDir == "" {
+		var err error
+		currentDir, err = os.Getwd()
+		if err != nil {
+			return "", err
+		}
 	}
 
-	var envVars []string
+	r.envVars = nil
 	for variable, value := range r.Config.EnvVars {
 		templated, err := r.Templater(value)
 		if err != nil {
 			return "", err
 		}
+		if strings.ContainsAny(templated, "\r\n") {
+			return "", fmt.Errorf("value of environment variable %s contains a newline", variable)
+		}
 		if templated != "" {
-			envVars = append(envVars, fmt.Sprintf("-e %s=%s", variable, templated))
+			r.envVars = append(r.envVars, fmt.Sprintf("%s=%s", variable, templated))
 		}
 	}
-	sort.Strings(envVars)
+	sort.Strings(r.envVars)
+	envFile := ""
+	if len(r.envVars) > 0 {
+		envFile = "--env-file " + containerEnvFile
+	}
 
 	var volumes []string
 	for _, c := range r.Config.Cache {
@@ -147,7 +185,7 @@ func (r *Runtime) getContainerCommand(interactive bool, cmd string) (string, err
 
 	return fmt.Sprintf(
 		"docker run --rm %s %s %s -w %s %s %s %s",
-		strings.Join(envVars, " "), strings.Join(volumes, " "), strings.Join(ports, " "), workDir, flags, r.Config.Image, cmd), nil
+		envFile, strings.Join(volumes, " "), strings.Join(ports, " "), workDir, flags, r.Config.Image, cmd), nil
 }
 
 func getGlobalCacheDir(r config.Runtime) (string, error) {
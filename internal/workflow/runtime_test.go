@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
@@ -130,7 +131,7 @@ func TestGetCommand(t *testing.T) {
 			runtime:       &Runtime{Config: buildContainer, Templater: withEnv.Template},
 			inputCommand:  "echo {{ .HI }}",
 			interactive:   true,
-			outputCommand: fmt.Sprintf("docker run --rm -e HI=hello -e ISSUES=false -e PORTS=1234,80 -v %s/.dredge/cache/go:/go -v %s:/home -p 8080:8080 -w /home -it build-image:latest echo hello", wd, wd),
+			outputCommand: fmt.Sprintf("docker run --rm --env-file /dev/fd/3 -v %s/.dredge/cache/go:/go -v %s:/home -p 8080:8080 -w /home -it build-image:latest echo hello", wd, wd),
 		},
 		"non-interactive container": {
 			runtime:       &Runtime{Config: buildContainer, Templater: emptyEnv.Template},
@@ -142,7 +143,7 @@ func TestGetCommand(t *testing.T) {
 			runtime:       &Runtime{Config: portContainer, Templater: withEnv.Template},
 			inputCommand:  "cmd",
 			interactive:   true,
-			outputCommand: fmt.Sprintf("docker run --rm -e HI=hello -e ISSUES=false -e PORTS=1234,80 -v %s/.dredge/cache/test:/test -v %s:/home -p 1234:1234 -p 80:80 -w /home -it port-image:latest cmd", wd, wd),
+			outputCommand: fmt.Sprintf("docker run --rm --env-file /dev/fd/3 -v %s/.dredge/cache/test:/test -v %s:/home -p 1234:1234 -p 80:80 -w /home -it port-image:latest cmd", wd, wd),
 		},
 		"container without ports": {
 			runtime:       &Runtime{Config: portContainer, Templater: emptyEnv.Template},
@@ -178,7 +179,7 @@ func TestGetCommand(t *testing.T) {
 			runtime:       &Runtime{Config: buildContainer, Templater: withEnv.Template},
 			inputCommand:  "test || out",
 			interactive:   true,
-			outputCommand: fmt.Sprintf("docker run --rm -e HI=hello -e ISSUES=false -e PORTS=1234,80 -v %s/.dredge/cache/go:/go -v %s:/home -p 8080:8080 -w /home -it build-image:latest test || out", wd, wd),
+			outputCommand: fmt.Sprintf("docker run --rm --env-file /dev/fd/3 -v %s/.dredge/cache/go:/go -v %s:/home -p 8080:8080 -w /home -it build-image:latest test || out", wd, wd),
 		},
 		"command with if": {
 			runtime:       &Runtime{Config: config.Runtime{Type: "native"}, Templater: withEnv.Template},
@@ -195,3 +196,47 @@ func TestGetCommand(t *testing.T) {
 		assert.Equal(t, test.outputCommand, cmd)
 	}
 }
+
+func TestContainerEnvFile(t *testing.T) {
+	withEnv := &CallbacksMock{
+		Env: map[string]interface{}{
+			"HI":    "hello",
+			"TOKEN": "s3cr3t",
+			"MULTI": "a\nb",
+		},
+	}
+	r := &Runtime{
+		Config:    config.Runtime{Type: "container", Image: "alpine", EnvVars: map[string]string{"HI": "{{.HI}}", "TOKEN": "{{.TOKEN}}", "EMPTY": "{{.EMPTY}}"}},
+		Templater: withEnv.Template,
+	}
+	cmd, err := r.GetCommand(false, "env")
+	assert.Nil(t, err)
+	assert.NotContains(t, cmd, "s3cr3t")
+	assert.Equal(t, []string{"HI=hello", "TOKEN=s3cr3t"}, r.envVars)
+
+	r.Config.EnvVars = map[string]string{"MULTI": "{{.MULTI}}"}
+	_, err = r.GetCommand(false, "env")
+	assert.Equal(t, "value of environment variable MULTI contains a newline", fmt.Sprint(err))
+
+	native := &Runtime{Config: config.Runtime{Type: "native"}, Templater: withEnv.Template, envVars: []string{"HI=hello", "TOKEN=s3cr3t"}}
+	var stdout bytes.Buffer
+	err = native.Execute(false, "cat "+containerEnvFile, nil, &stdout, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "HI=hello\nTOKEN=s3cr3t\n", stdout.String())
+}
+
+func TestRuntimeDir(t *testing.T) {
+	dir := t.TempDir()
+	templater := (&CallbacksMock{}).Template
+
+	native := &Runtime{Config: config.Runtime{Type: "native"}, Templater: templater, Dir: dir}
+	var stdout bytes.Buffer
+	err := native.Execute(false, "pwd", nil, &stdout, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, dir+"\n", stdout.String())
+
+	container := &Runtime{Config: config.Runtime{Type: "container", Image: "alpine", Home: "/src"}, Templater: templater, Dir: dir}
+	cmd, err := container.GetCommand(false, "ls")
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf("docker run --rm  -v %s:/src  -w /src  alpine ls", dir), cmd)
+}
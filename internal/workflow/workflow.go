@@ -3,6 +3,7 @@ package workflow
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/dredge-dev/dredge/internal/api"
@@ -17,15 +18,49 @@ type Bucket struct {
 }
 
 type Workflow struct {
+	ID          string
 	Name        string
 	Description string
+	Needs       []*Workflow
 	Inputs      []config.Input
 	Steps       []config.Step
 	Runtimes    []config.Runtime
 	Callbacks   api.Callbacks
+	Dir         string
+	Force       bool
+	stdout      io.Writer
+	stderr      io.Writer
 }
 
+// Execute runs the workflows this workflow needs, followed by the workflow
+// itself. Every workflow runs at most once.
 func (workflow *Workflow) Execute() error {
+	return workflow.execute(make(map[string]bool))
+}
+
+func (workflow *Workflow) execute(executed map[string]bool) error {
+	for _, need := range workflow.Needs {
+		if executed[need.key()] {
+			continue
+		}
+		need.Force = workflow.Force
+		if err := need.execute(executed); err != nil {
+			return fmt.Errorf("workflow %s: %v", need.Name, err)
+		}
+	}
+	executed[workflow.key()] = true
+	return workflow.ExecuteOnly()
+}
+
+func (workflow *Workflow) key() string {
+	if workflow.ID == "" {
+		return fmt.Sprintf("%p", workflow)
+	}
+	return workflow.ID
+}
+
+// ExecuteOnly runs the workflow without the workflows it needs.
+func (workflow *Workflow) ExecuteOnly() error {
 	// TODO Re-arrange to get all inputs at once
 	for _, input := range workflow.Inputs {
 		skip, err := workflow.Callbacks.Template(input.Skip)
@@ -34,13 +69,17 @@ func (workflow *Workflow) Execute() error {
 		}
 		if skip != "true" {
 			result, err := workflow.Callbacks.RequestInput([]api.InputRequest{
-				toInputRequest(input),
+				api.NewInputRequest(input),
 			})
 			if err != nil {
 				return err
 			}
+			var value interface{} = result[input.Name]
+			if input.Type == config.INPUT_MULTISELECT {
+				value = config.SplitList(result[input.Name])
+			}
 			// TODO Should this be moved to Exec? Should every RequestIput set the env?
-			if err := workflow.Callbacks.SetEnv(input.Name, result[input.Name]); err != nil {
+			if err := workflow.Callbacks.SetEnv(input.Name, value); err != nil {
 				return err
 			}
 		}
@@ -48,23 +87,6 @@ func (workflow *Workflow) Execute() error {
 	return workflow.executeSteps(workflow.Steps)
 }
 
-func toInputRequest(input config.Input) api.InputRequest {
-	return api.InputRequest{
-		Name:         input.Name,
-		Description:  input.Description,
-		Type:         toInputType(input.Type),
-		Values:       input.Values,
-		DefaultValue: input.DefaultValue,
-	}
-}
-
-func toInputType(t string) api.InputType {
-	if t == config.INPUT_SELECT {
-		return api.Select
-	}
-	return api.Text
-}
-
 func (workflow *Workflow) executeSteps(steps []config.Step) error {
 	for _, step := range steps {
 		err := workflow.executeStep(step)
@@ -94,6 +116,10 @@ func (workflow *Workflow) executeStep(step config.Step) error {
 		return workflow.executeLogStep(step.Log)
 	} else if step.Confirm != nil {
 		return workflow.executeConfirmStep(step.Confirm)
+	} else if step.Parallel != nil {
+		return workflow.executeParallelStep(step.Parallel)
+	} else if step.Foreach != nil {
+		return workflow.executeForeachStep(step.Foreach)
 	}
 	return fmt.Errorf("no execution found for step %v", step.Name)
 }
@@ -103,26 +129,49 @@ func (workflow *Workflow) executeShellStep(shell *config.ShellStep) error {
 	if err != nil {
 		return err
 	}
-	interactive := true
-	var stdout *bytes.Buffer
+	var cache *stepCache
+	if len(shell.Sources) > 0 {
+		cache, err = workflow.newStepCache(shell, runtime)
+		if err != nil {
+			return err
+		}
+		if !workflow.Force {
+			upToDate, err := cache.UpToDate()
+			if err != nil {
+				return err
+			}
+			if upToDate {
+				return workflow.Callbacks.Log(api.Info, "Skipping %s: sources and outputs did not change", shell.Cmd)
+			}
+		}
+	}
+	// Steps running in a parallel branch write to a prefixed output and can't be interactive
+	interactive := workflow.stdout == nil
+	stdout, stderr := workflow.stdout, workflow.stderr
+	var stdoutBuffer *bytes.Buffer
 	if shell.StdOut != "" {
-		stdout = new(bytes.Buffer)
+		stdoutBuffer = new(bytes.Buffer)
+		stdout = stdoutBuffer
 		interactive = false
 	}
-	var stderr *bytes.Buffer
+	var stderrBuffer *bytes.Buffer
 	if shell.StdErr != "" {
-		stderr = new(bytes.Buffer)
+		stderrBuffer = new(bytes.Buffer)
+		stderr = stderrBuffer
 		interactive = false
 	}
 	err = runtime.Execute(interactive, shell.Cmd, nil, stdout, stderr)
 	if err != nil {
 		return err
 	}
-	if shell.StdOut != "" && stdout != nil {
-		workflow.Callbacks.SetEnv(shell.StdOut, stdout.String())
+	if stdoutBuffer != nil {
+		workflow.Callbacks.SetEnv(shell.StdOut, stdoutBuffer.String())
+	}
+	if stderrBuffer != nil {
+		workflow.Callbacks.SetEnv(shell.StdErr, stderrBuffer.String())
 	}
-	if shell.StdErr != "" && stderr != nil {
-		workflow.Callbacks.SetEnv(shell.StdErr, stderr.String())
+	if cache != nil {
+		return cache.Save()
 	}
 	return nil
 }
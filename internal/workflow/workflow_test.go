@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/dredge-dev/dredge/internal/api"
@@ -21,6 +22,7 @@ type CallbacksMock struct {
 	MConfirm                    func(msg string, args ...interface{}) (bool, error)
 	MExecuteResourceCommand     func(resource string, command string) (*api.CommandOutput, error)
 	MSetEnv                     func(name string, value interface{}) error
+	MGetEnv                     func(name string) (interface{}, bool)
 	MTemplate                   func(input string) (string, error)
 	MAddVariablesToDredgefile   func(variable map[string]string) error
 	MAddWorkflowToDredgefile    func(workflow config.Workflow) error
@@ -28,6 +30,7 @@ type CallbacksMock struct {
 	MAddProviderToDredgefile    func(resource, provider string, providerConfig map[string]string) error
 	MRelativePathFromDredgefile func(path string) (string, error)
 	Env                         map[string]interface{}
+	envLock                     sync.Mutex
 }
 
 func (c *CallbacksMock) Log(level api.LogLevel, msg string, args ...interface{}) error {
@@ -40,6 +43,8 @@ func (c *CallbacksMock) RequestInput(inputRequests []api.InputRequest) (map[stri
 	if c.MRequestInput != nil {
 		return c.MRequestInput(inputRequests)
 	}
+	c.envLock.Lock()
+	defer c.envLock.Unlock()
 	ret := make(map[string]string)
 	for _, request := range inputRequests {
 		ret[request.Name] = fmt.Sprintf("%s", c.Env[request.Name])
@@ -68,12 +73,50 @@ func (c *CallbacksMock) SetEnv(name string, value interface{}) error {
 	if c.MSetEnv != nil {
 		return c.MSetEnv(name, value)
 	}
+	c.envLock.Lock()
+	defer c.envLock.Unlock()
 	if c.Env == nil {
 		c.Env = make(map[string]interface{})
 	}
 	c.Env[name] = value
 	return nil
 }
+func (c *CallbacksMock) GetEnv(name string) (interface{}, bool) {
+	if c.MGetEnv != nil {
+		return c.MGetEnv(name)
+	}
+	c.envLock.Lock()
+	defer c.envLock.Unlock()
+	value, ok := c.Env[name]
+	return value, ok
+}
+func (c *CallbacksMock) Scope(vars map[string]interface{}) api.Callbacks {
+	c.envLock.Lock()
+	defer c.envLock.Unlock()
+	env := make(map[string]interface{})
+	for key, value := range c.Env {
+		env[key] = value
+	}
+	for key, value := range vars {
+		env[key] = value
+	}
+	return &CallbacksMock{
+		MLog:                        c.MLog,
+		MRequestInput:               c.MRequestInput,
+		MOpenUrl:                    c.MOpenUrl,
+		MConfirm:                    c.MConfirm,
+		MExecuteResourceCommand:     c.MExecuteResourceCommand,
+		MSetEnv:                     c.MSetEnv,
+		MGetEnv:                     c.MGetEnv,
+		MTemplate:                   c.MTemplate,
+		MAddVariablesToDredgefile:   c.MAddVariablesToDredgefile,
+		MAddWorkflowToDredgefile:    c.MAddWorkflowToDredgefile,
+		MAddBucketToDredgefile:      c.MAddBucketToDredgefile,
+		MAddProviderToDredgefile:    c.MAddProviderToDredgefile,
+		MRelativePathFromDredgefile: c.MRelativePathFromDredgefile,
+		Env:                         env,
+	}
+}
 func (c *CallbacksMock) Template(input string) (string, error) {
 	if c.MTemplate != nil {
 		return c.MTemplate(input)
@@ -82,6 +125,8 @@ func (c *CallbacksMock) Template(input string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %s", err)
 	}
+	c.envLock.Lock()
+	defer c.envLock.Unlock()
 	var buffer bytes.Buffer
 	if err := t.Execute(&buffer, c.Env); err != nil {
 		return "", err
@@ -352,3 +397,59 @@ func TestExecuteConfirmStepTemplated(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "your message to be confirmed", message)
 }
+
+func TestExecuteNeeds(t *testing.T) {
+	var executed []string
+	c := &CallbacksMock{
+		MLog: func(level api.LogLevel, msg string, args ...interface{}) error {
+			executed = append(executed, msg)
+			return nil
+		},
+	}
+	newWorkflow := func(name string, needs ...*Workflow) *Workflow {
+		return &Workflow{
+			ID:    "./Dredgefile:" + name,
+			Name:  name,
+			Needs: needs,
+			Steps: []config.Step{
+				{Log: &config.LogStep{Level: config.LOG_INFO, Message: name}},
+			},
+			Callbacks: c,
+		}
+	}
+	build := newWorkflow("build")
+	test := newWorkflow("test", newWorkflow("build"))
+	deploy := newWorkflow("deploy", build, test)
+
+	err := deploy.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"build", "test", "deploy"}, executed)
+
+	executed = nil
+	err = deploy.ExecuteOnly()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deploy"}, executed)
+}
+
+func TestExecuteMultiSelectInput(t *testing.T) {
+	c := &CallbacksMock{
+		MRequestInput: func(inputRequests []api.InputRequest) (map[string]string, error) {
+			assert.Equal(t, api.MultiSelect, inputRequests[0].Type)
+			return map[string]string{"services": "api, web"}, nil
+		},
+	}
+	workflow := &Workflow{
+		Name: "workflow",
+		Inputs: []config.Input{
+			{Name: "services", Type: config.INPUT_MULTISELECT, Values: []string{"api", "web", "worker"}},
+		},
+		Steps: []config.Step{
+			{Set: &config.SetStep{"done": "true"}},
+		},
+		Callbacks: c,
+	}
+
+	err := workflow.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api", "web"}, c.Env["services"])
+}
@@ -17,19 +17,31 @@ func main() {
 	var de *exec.DredgeExec
 
 	source := DefaultDredgefilePath
-	c := cmd.CliCallbacks{Reader: os.Stdin, Writer: os.Stdout, Verbose: &cmd.Verbose}
+	c := cmd.CliCallbacks{Reader: os.Stdin, Writer: os.Stdout, Verbose: &cmd.Verbose, NonInteractive: &cmd.NonInteractive}
 	rd := resource.GetDefaultResourceDefinitions()
 
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Error while reading the working directory: %v", err)
+	}
+	// Use the nearest Dredgefile, workflows run in the directory of the
+	// Dredgefile
+	if dir, err := exec.FindDredgefile(wd); err == nil {
+		if err := os.Chdir(dir); err != nil {
+			log.Fatalf("Error while changing directory to %s: %v", dir, err)
+		}
+	}
+
 	if _, err := os.Stat(source); errors.Is(err, os.ErrNotExist) {
 		de = exec.EmptyExec(config.SourcePath(source), rd, c)
 	} else {
-		de, err = exec.NewExec(config.SourcePath(source), rd, c)
+		de, err = exec.NewExec(config.SourcePath(source), cmd.GetProfile(os.Args[1:]), rd, c)
 		if err != nil {
 			log.Fatalf("Error while reading Dredgefile: %s\n", err)
 		}
 	}
 
-	err := cmd.Init(de)
+	err = cmd.Init(de)
 	if err != nil {
 		log.Fatalf("Error during init: %v", err)
 	}